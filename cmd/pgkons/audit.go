@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jsteenb2/pgkons/internal/audit"
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+func auditCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	profile := fs.String("profile", "", "name of the saved config to connect with")
+	format := fs.String("format", "text", "output format: text or json")
+	failOn := fs.String("fail-on", "warning", "exit non-zero when a finding is at least this severe: info, warning or critical")
+	failOnSkipped := fs.Bool("fail-on-skipped", true, "exit non-zero when a check could not run, e.g. for lack of privileges")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	threshold, err := audit.ParseSeverity(*failOn)
+	if err != nil {
+		return err
+	}
+
+	db, err := connect(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	findings, err := audit.Run(ctx, postgres.New(db))
+	if err != nil {
+		return err
+	}
+	if err := audit.Write(os.Stdout, findings, *format); err != nil {
+		return err
+	}
+	if failing := audit.AtLeast(findings, threshold); len(failing) > 0 {
+		return fmt.Errorf("%d finding(s) at or above %s", len(failing), threshold)
+	}
+	if skipped := audit.Skipped(findings); *failOnSkipped && len(skipped) > 0 {
+		return fmt.Errorf("%d check(s) skipped", len(skipped))
+	}
+	return nil
+}
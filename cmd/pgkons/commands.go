@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/jsteenb2/pgkons/internal/runner"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"audit": {
+		usage: "check a cluster for common security weaknesses",
+		run:   auditCmd,
+	},
+	"dict": {
+		usage: "write a data dictionary of every user schema as markdown or html",
+		run:   dictCmd,
+	},
+	"erd": {
+		usage: "write an entity-relationship diagram of a schema",
+		run:   erdCmd,
+	},
+	"schema": {
+		usage: "export tables and composite types as JSON Schema or OpenAPI components",
+		run:   schemaCmd,
+	},
+	"settings": {
+		usage: "compare the server settings of two profiles",
+		run:   settingsCmd,
+	},
+	"snapshot": {
+		usage: "write the catalog of a database to a portable JSON snapshot",
+		run:   snapshotCmd,
+	},
+	"diff": {
+		usage: "compare the schemas of two profiles or snapshot files",
+		run:   diffCmd,
+	},
+	"gen": {
+		usage: "generate Go structs and sqlx queries for tables",
+		run:   genCmd,
+	},
+	"lint": {
+		usage: "check the schema of a profile or snapshot for design smells",
+		run:   lintCmd,
+	},
+	"migrate": {
+		usage: "write the SQL that brings one profile or snapshot in line with another",
+		run:   migrateCmd,
+	},
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "usage: %s [flags] [command]\n\nwithout a command the interactive explorer is started\n\ncommands:\n", os.Args[0])
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(w, "\nflags:\n")
+	flag.PrintDefaults()
+}
+
+// connect opens a connection to the saved configuration with the given name,
+// or asks for the connection details when profile is empty.
+func connect(ctx context.Context, profile string) (*sqlx.DB, error) {
+	find := runner.SelectDBCFG
+	if profile != "" {
+		find = func() (runner.CFG, error) { return runner.FindConfig(profile) }
+	}
+	cfg, err := find()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Open(ctx)
+}
+
+// output opens the file at path for writing, or stdout when path is empty.
+func output(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
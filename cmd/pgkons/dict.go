@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/jsteenb2/pgkons/internal/dictionary"
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+func dictCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("dict", flag.ExitOnError)
+	profile := fs.String("profile", "", "name of the saved config to connect with")
+	format := fs.String("format", string(dictionary.Markdown), "output format: markdown or html")
+	out := fs.String("out", "", "file to write the dictionary to, defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := connect(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	snap, err := postgres.New(db).Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	w, err := output(*out)
+	if err != nil {
+		return err
+	}
+	if err := dictionary.Write(w, dictionary.New(snap), dictionary.Format(*format)); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
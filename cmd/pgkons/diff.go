@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jsteenb2/pgkons/internal/runner"
+	"github.com/jsteenb2/pgkons/internal/schemadiff"
+)
+
+func diffCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pgkons diff [flags] <profile|snapshot> <profile|snapshot>")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("diff needs exactly two profiles or snapshot files")
+	}
+
+	from, err := runner.LoadSnapshot(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := runner.LoadSnapshot(ctx, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	changes := schemadiff.Compare(from, to)
+	switch *format {
+	case "text":
+		return schemadiff.WriteText(os.Stdout, changes)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		return enc.Encode(changes)
+	default:
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/erd"
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+func erdCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("erd", flag.ExitOnError)
+	profile := fs.String("profile", "", "name of the saved config to connect with")
+	schema := fs.String("schema", "public", "schema to diagram")
+	tables := fs.String("tables", "", "comma separated tables to include, defaults to every table in the schema")
+	format := fs.String("format", string(erd.Mermaid), "diagram format: mermaid, dot or plantuml")
+	out := fs.String("out", "", "file to write the diagram to, defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := connect(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	client := postgres.New(db)
+	cols, err := client.Columns(ctx, *schema)
+	if err != nil {
+		return err
+	}
+	keys, err := client.SchemaForeignKeys(ctx, *schema)
+	if err != nil {
+		return err
+	}
+
+	var include []string
+	if *tables != "" {
+		include = strings.Split(*tables, ",")
+	}
+
+	w, err := output(*out)
+	if err != nil {
+		return err
+	}
+	if err := erd.Write(w, erd.New(cols, keys, include), erd.Format(*format)); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/codegen"
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+func genCmd(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "go" {
+		return errors.New("usage: pgkons gen go [flags], go is the only supported language")
+	}
+
+	fs := flag.NewFlagSet("gen go", flag.ExitOnError)
+	profile := fs.String("profile", "", "name of the saved config to connect with")
+	schema := fs.String("schema", "public", "schema of the tables")
+	tables := fs.String("table", "", "comma separated tables to generate code for, defaults to every table in the schema")
+	pkg := fs.String("package", "models", "package name of the generated file")
+	nullable := fs.String("nullable", string(codegen.NullableSQL), "how nullable columns are represented: sql (sql.NullString etc) or pointer")
+	crud := fs.Bool("crud", true, "generate get, list, insert, update and delete functions")
+	out := fs.String("out", "", "file to write the code to, defaults to stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	opts := codegen.GoOptions{
+		Package:  *pkg,
+		Nullable: codegen.Nullable(*nullable),
+		CRUD:     *crud,
+	}
+	if opts.Nullable != codegen.NullableSQL && opts.Nullable != codegen.NullablePointer {
+		return fmt.Errorf("unsupported nullable mode %q", *nullable)
+	}
+
+	db, err := connect(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	client := postgres.New(db)
+
+	cols, err := client.Columns(ctx, *schema)
+	if err != nil {
+		return err
+	}
+	var names []string
+	if *tables != "" {
+		names = strings.Split(*tables, ",")
+	}
+	selected, err := codegen.Tables(cols, names)
+	if err != nil {
+		return err
+	}
+	types, err := client.Types(ctx)
+	if err != nil {
+		return err
+	}
+
+	w, err := output(*out)
+	if err != nil {
+		return err
+	}
+	if err := codegen.Go(w, selected, types, opts); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jsteenb2/pgkons/internal/lint"
+	"github.com/jsteenb2/pgkons/internal/postgres"
+	"github.com/jsteenb2/pgkons/internal/runner"
+)
+
+func lintCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pgkons lint [flags] [profile|snapshot]")
+		fs.PrintDefaults()
+	}
+	config := fs.String("config", os.Getenv("HOME")+"/.pgkons/lint.json", "file turning rules on and off and setting naming conventions")
+	format := fs.String("format", "text", "output format: text, json or sarif")
+	out := fs.String("out", "", "file to write the findings to, defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("lint takes at most one profile or snapshot file")
+	}
+
+	cfg, err := lint.LoadConfig(*config)
+	if err != nil {
+		return err
+	}
+
+	var snap *postgres.Snapshot
+	if fs.NArg() == 1 {
+		snap, err = runner.LoadSnapshot(ctx, fs.Arg(0))
+	} else {
+		snap, err = connectSnapshot(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	findings, err := lint.Run(snap, cfg)
+	if err != nil {
+		return err
+	}
+
+	w, err := output(*out)
+	if err != nil {
+		return err
+	}
+	if err := lint.Write(w, findings, *format); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// connectSnapshot asks for connection details and captures a snapshot of the
+// database.
+func connectSnapshot(ctx context.Context) (*postgres.Snapshot, error) {
+	db, err := connect(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return postgres.New(db).Snapshot(ctx)
+}
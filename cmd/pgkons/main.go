@@ -13,17 +13,44 @@ import (
 	_ "github.com/lib/pq"
 )
 
-var debug = flag.Bool("debug", true, "turn debug on to view corresponding errors and what not in the console")
+var (
+	debug        = flag.Bool("debug", true, "turn debug on to view corresponding errors and what not in the console")
+	snapshotFile = flag.String("snapshot", "", "explore a snapshot file written by the snapshot command instead of a database")
+)
 
 func main() {
-	connect, err := runner.NewDBCFG()
+	flag.Usage = usage
+	flag.Parse()
+
+	if cmd, ok := commands[flag.Arg(0)]; ok {
+		if err := cmd.run(systemCtx(), flag.Args()[1:]); err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *snapshotFile != "" {
+		snap, err := runner.ReadSnapshotFile(*snapshotFile)
+		if err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+		err = runner.NewOffline(snap).Run(systemCtx(), *debug)
+		if *debug && err != nil && err != context.Canceled {
+			log.Println(err)
+		}
+		return
+	}
+
+	cfg, err := runner.SelectDBCFG()
 	if err != nil {
 		if *debug {
 			log.Println(err)
 			os.Exit(1)
 		}
 	}
-	db, err := sql.Open("postgres", connect)
+	db, err := sql.Open("postgres", cfg.DBConnection())
 	if err != nil {
 		check(err)
 		os.Exit(1)
@@ -38,7 +65,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	r := runner.New(db)
+	r := runner.New(db, cfg)
 	err = r.Run(ctx, *debug)
 	if *debug && err != nil &&
 		err != context.Canceled {
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jsteenb2/pgkons/internal/migrate"
+	"github.com/jsteenb2/pgkons/internal/postgres"
+	"github.com/jsteenb2/pgkons/internal/runner"
+)
+
+func migrateCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pgkons migrate [flags] <from profile|snapshot> <to profile|snapshot>")
+		fmt.Fprintln(fs.Output(), "\nwrites the SQL that brings <from> in line with <to>")
+		fs.PrintDefaults()
+	}
+	out := fs.String("out", "", "file to write the migration to, defaults to stdout")
+	verify := fs.Bool("verify", false, "apply the migration to <from> in a rolled back transaction to check it runs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("migrate needs exactly two profiles or snapshot files")
+	}
+
+	from, err := runner.LoadSnapshot(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := runner.LoadSnapshot(ctx, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	stmts := migrate.Generate(from, to)
+
+	w, err := output(*out)
+	if err != nil {
+		return err
+	}
+	if err := migrate.Write(w, stmts); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if !*verify {
+		return nil
+	}
+	if _, err := os.Stat(fs.Arg(0)); err == nil {
+		return errors.New("--verify needs <from> to be a profile, not a snapshot file")
+	}
+	db, err := connect(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := migrate.Verify(ctx, postgres.New(db), stmts); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "migration applied cleanly and was rolled back")
+	return nil
+}
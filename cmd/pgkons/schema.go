@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/jsonschema"
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+func schemaCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	profile := fs.String("profile", "", "name of the saved config to connect with")
+	schema := fs.String("schema", "public", "schema of the tables")
+	tables := fs.String("tables", "", "comma separated tables to export, defaults to every table in the schema")
+	format := fs.String("format", string(jsonschema.JSONSchema), "output format: jsonschema or openapi")
+	out := fs.String("out", "", "file to write the schemas to, defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := connect(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	snap, err := postgres.New(db).Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	var names []string
+	if *tables != "" {
+		names = strings.Split(*tables, ",")
+	}
+
+	w, err := output(*out)
+	if err != nil {
+		return err
+	}
+	if err := jsonschema.Write(w, snap, *schema, names, jsonschema.Format(*format)); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+func settingsCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("settings", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: pgkons settings [flags] <profile> <profile>")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "table", "output format: table or json")
+	all := fs.Bool("all", false, "include settings naming files or the cluster, which differ between any two servers")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("settings needs exactly two profiles")
+	}
+
+	from, err := profileSettings(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := profileSettings(ctx, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	diffs := postgres.CompareSettings(from, to, *all)
+	switch *format {
+	case "table":
+		return postgres.WriteSettingDiffs(os.Stdout, fs.Arg(0), fs.Arg(1), diffs)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		return enc.Encode(diffs)
+	default:
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+}
+
+func profileSettings(ctx context.Context, profile string) ([]postgres.Setting, error) {
+	db, err := connect(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return postgres.New(db).Settings(ctx)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+func snapshotCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	profile := fs.String("profile", "", "name of the saved config to connect with")
+	out := fs.String("out", "", "file to write the snapshot to, defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := connect(ctx, *profile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	snap, err := postgres.New(db).Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	w, err := output(*out)
+	if err != nil {
+		return err
+	}
+	if err := postgres.WriteSnapshot(w, snap); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
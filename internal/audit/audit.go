@@ -0,0 +1,325 @@
+// Package audit checks a cluster for common security weaknesses, such as
+// privileges granted to PUBLIC, superusers that can log in and pg_hba.conf
+// entries that skip authentication.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+// Severity ranks how urgent a finding is.
+type Severity int
+
+// Severities from least to most urgent.
+const (
+	Info Severity = iota
+	Warning
+	Critical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Critical:
+		return "critical"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// MarshalText writes the severity by name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity parses a severity name as written by String.
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{Info, Warning, Critical} {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q", name)
+}
+
+// Finding is a weakness found by a check, on the object it names.
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Object   string   `json:"object"`
+	Message  string   `json:"message"`
+	// Skipped is set on the info finding standing in for a check that could
+	// not run.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s %s: %s", f.Severity, f.Check, f.Object, f.Message)
+}
+
+type check struct {
+	name string
+	run  func(ctx context.Context, c *postgres.Client) ([]Finding, error)
+}
+
+var checks = []check{
+	{"public-grants", publicGrants},
+	{"roles", roles},
+	{"security-definer-search-path", securityDefiners},
+	{"ssl", connections},
+	{"hba", hbaRules},
+}
+
+// Run runs every check against the database and returns the findings, most
+// severe first. A check that cannot run, e.g. for lack of privileges, is
+// reported as an info finding instead of failing the audit.
+func Run(ctx context.Context, c *postgres.Client) ([]Finding, error) {
+	var findings []Finding
+	for _, chk := range checks {
+		found, err := chk.run(ctx, c)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			findings = append(findings, Finding{
+				Check:    chk.name,
+				Severity: Info,
+				Object:   "-",
+				Message:  "check skipped: " + err.Error(),
+				Skipped:  true,
+			})
+			continue
+		}
+		findings = append(findings, found...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity > findings[j].Severity
+	})
+	return findings, nil
+}
+
+// Skipped returns the findings standing in for checks that could not run.
+func Skipped(findings []Finding) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if f.Skipped {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// AtLeast returns the findings of the given severity or above.
+func AtLeast(findings []Finding, min Severity) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if f.Severity >= min {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Write renders the findings as an aligned text table or as JSON.
+func Write(w io.Writer, findings []Finding, format string) error {
+	switch format {
+	case "text":
+		if len(findings) == 0 {
+			_, err := fmt.Fprintln(w, "no findings")
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, f := range findings {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", strings.ToUpper(f.Severity.String()), f.Check, f.Object, f.Message)
+		}
+		return tw.Flush()
+	case "json":
+		if findings == nil {
+			findings = []Finding{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(findings)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func publicGrants(ctx context.Context, c *postgres.Client) ([]Finding, error) {
+	grants, err := c.PublicGrants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, g := range grants {
+		f := Finding{Check: "public-grants", Severity: Warning}
+		switch g.Kind {
+		case "schema":
+			f.Object = "schema " + g.Schema
+			f.Message = "PUBLIC can create objects in the schema, any role can shadow objects other roles resolve through search_path"
+		default:
+			f.Object = "table " + g.Schema + "." + g.Name
+			f.Message = "PUBLIC can read the table, every role sees its rows"
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+func roles(ctx context.Context, c *postgres.Client) ([]Finding, error) {
+	rs, err := c.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, r := range rs {
+		if !r.Login {
+			continue
+		}
+		if r.Superuser {
+			findings = append(findings, Finding{
+				Check:    "roles",
+				Severity: Warning,
+				Object:   "role " + r.Name,
+				Message:  "superuser can log in, prefer logging in as an unprivileged role and SET ROLE when needed",
+			})
+		}
+		if r.ValidUntil == "" || r.ValidUntil == "infinity" {
+			findings = append(findings, Finding{
+				Check:    "roles",
+				Severity: Info,
+				Object:   "role " + r.Name,
+				Message:  "password never expires",
+			})
+		}
+	}
+	return findings, nil
+}
+
+func securityDefiners(ctx context.Context, c *postgres.Client) ([]Finding, error) {
+	fns, err := c.SecurityDefinerFunctions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := c.PublicGrants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	writable := make(map[string]bool)
+	for _, g := range grants {
+		if g.Kind == "schema" {
+			writable[g.Schema] = true
+		}
+	}
+
+	var findings []Finding
+	for _, fn := range fns {
+		finding := Finding{
+			Check:    "security-definer-search-path",
+			Severity: Critical,
+			Object:   "function " + fn.Schema + "." + fn.Name,
+		}
+		if fn.SearchPath == "" {
+			finding.Message = fmt.Sprintf("runs as %s without a fixed search_path, callers can hijack the names it uses; add SET search_path", fn.Owner)
+			findings = append(findings, finding)
+			continue
+		}
+
+		path := searchPath(fn.SearchPath, fn.Owner)
+		for _, schema := range path {
+			if writable[schema] {
+				finding.Message = fmt.Sprintf("runs as %s with %s on its search_path, where every role can create objects that hijack the names it uses", fn.Owner, schema)
+				findings = append(findings, finding)
+				break
+			}
+		}
+		if path[len(path)-1] != "pg_temp" {
+			finding.Severity = Warning
+			finding.Message = fmt.Sprintf("runs as %s with a search_path not ending in pg_temp, so callers' temporary tables are searched first; add pg_temp last", fn.Owner)
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// searchPath splits a search_path setting into its schemas, with $user
+// standing for role.
+func searchPath(setting, role string) []string {
+	var path []string
+	for _, schema := range strings.Split(setting, ",") {
+		schema = strings.TrimSpace(schema)
+		if strings.HasPrefix(schema, `"`) && strings.HasSuffix(schema, `"`) && len(schema) > 1 {
+			schema = strings.Replace(schema[1:len(schema)-1], `""`, `"`, -1)
+		}
+		if schema == "$user" {
+			schema = role
+		}
+		path = append(path, schema)
+	}
+	return path
+}
+
+func connections(ctx context.Context, c *postgres.Client) ([]Finding, error) {
+	conns, err := c.Connections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, conn := range conns {
+		if conn.SSL {
+			continue
+		}
+		findings = append(findings, Finding{
+			Check:    "ssl",
+			Severity: Warning,
+			Object:   fmt.Sprintf("backend %d", conn.PID),
+			Message:  fmt.Sprintf("%s@%s from %s is connected without SSL", conn.Username, conn.Database, conn.ClientAddr),
+		})
+	}
+	return findings, nil
+}
+
+func hbaRules(ctx context.Context, c *postgres.Client) ([]Finding, error) {
+	rules, err := c.HBARules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, r := range rules {
+		object := fmt.Sprintf("pg_hba.conf line %d", r.Line)
+		scope := fmt.Sprintf("%s %s %s %s", r.Type, strings.Join(r.Databases, ","), strings.Join(r.Users, ","), r.Address)
+		switch r.AuthMethod {
+		case "trust":
+			severity := Critical
+			if r.Type == "local" {
+				severity = Warning
+			}
+			findings = append(findings, Finding{
+				Check:    "hba",
+				Severity: severity,
+				Object:   object,
+				Message:  "trust lets anyone connect without a password: " + strings.TrimSpace(scope),
+			})
+		case "password":
+			findings = append(findings, Finding{
+				Check:    "hba",
+				Severity: Warning,
+				Object:   object,
+				Message:  "password sends passwords in clear text, use scram-sha-256: " + strings.TrimSpace(scope),
+			})
+		}
+	}
+	return findings, nil
+}
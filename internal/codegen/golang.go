@@ -0,0 +1,560 @@
+// Package codegen generates code for working with a database's tables.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/lib/pq"
+)
+
+// Nullable decides how nullable columns are represented in Go.
+type Nullable string
+
+const (
+	// NullableSQL uses the database/sql Null types, e.g. sql.NullString.
+	NullableSQL Nullable = "sql"
+	// NullablePointer uses pointers, e.g. *string.
+	NullablePointer Nullable = "pointer"
+)
+
+type (
+	// GoOptions configures the generated Go code.
+	GoOptions struct {
+		Package  string
+		Nullable Nullable
+		// CRUD adds get, list, insert, update and delete functions for
+		// every table.
+		CRUD bool
+	}
+
+	// Table is a table to generate code for.
+	Table struct {
+		Schema  string
+		Name    string
+		Columns []postgres.TableColumn
+	}
+)
+
+// Tables groups columns into their tables, keeping the order of cols. When
+// names is not empty only the named tables are returned, an unknown name is
+// an error.
+func Tables(cols []postgres.TableColumn, names []string) ([]Table, error) {
+	var (
+		tables []Table
+		index  = make(map[string]int)
+	)
+	for _, c := range cols {
+		i, ok := index[c.Table]
+		if !ok {
+			i = len(tables)
+			index[c.Table] = i
+			tables = append(tables, Table{Schema: c.Schema, Name: c.Table})
+		}
+		tables[i].Columns = append(tables[i].Columns, c)
+	}
+	if len(names) == 0 {
+		return tables, nil
+	}
+
+	var selected []Table
+	for _, name := range names {
+		i, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("table %q not found", name)
+		}
+		selected = append(selected, tables[i])
+	}
+	return selected, nil
+}
+
+// Go writes a gofmt'ed Go file with a struct for every table, string
+// constants for the enum types the tables use and, when opts.CRUD is set,
+// sqlx query functions for each table.
+func Go(w io.Writer, tables []Table, enums []postgres.Type, opts GoOptions) error {
+	g := goGen{
+		opts:    opts,
+		imports: make(map[string]bool),
+		enums:   make(map[string]postgres.Type),
+		used:    make(map[string]postgres.Type),
+
+		names:     make(map[string]bool),
+		enumNames: make(map[string]string),
+	}
+	for _, t := range tables {
+		g.names[exported(singular(t.Name))] = true
+	}
+	for _, e := range enums {
+		if e.Kind != "enum" {
+			continue
+		}
+		g.enums[e.Schema+"."+e.Name] = e
+		if _, ok := g.enums[e.Name]; !ok || e.Schema == "public" {
+			g.enums[e.Name] = e
+		}
+	}
+
+	var body bytes.Buffer
+	for _, t := range tables {
+		g.table(&body, t)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by pgkons gen go. DO NOT EDIT.\n\npackage %s\n\n", opts.Package)
+	if len(g.imports) > 0 {
+		var std, external []string
+		for imp := range g.imports {
+			if strings.Contains(imp, ".") {
+				external = append(external, imp)
+			} else {
+				std = append(std, imp)
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(external)
+
+		fmt.Fprintln(&out, "import (")
+		for _, imp := range std {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+		if len(std) > 0 && len(external) > 0 {
+			fmt.Fprintln(&out)
+		}
+		for _, imp := range external {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+		fmt.Fprintln(&out, ")")
+	}
+	g.enumConstants(&out)
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting generated code: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type goGen struct {
+	opts    GoOptions
+	imports map[string]bool
+	enums   map[string]postgres.Type
+	used    map[string]postgres.Type
+	// names holds the Go type names taken by tables and enums, enumNames
+	// the name given to each enum.
+	names     map[string]bool
+	enumNames map[string]string
+}
+
+// enumName returns the Go type name of an enum, its exported name unless a
+// table or another enum already took that.
+func (g *goGen) enumName(e postgres.Type) string {
+	key := e.Schema + "." + e.Name
+	if name, ok := g.enumNames[key]; ok {
+		return name
+	}
+	name := exported(e.Name)
+	for g.names[name] {
+		name += "Enum"
+	}
+	g.names[name] = true
+	g.enumNames[key] = name
+	return name
+}
+
+func (g *goGen) enumConstants(w io.Writer) {
+	var names []string
+	for name := range g.used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		e := g.used[name]
+		fmt.Fprintf(w, "\n// %s is the %s.%s enum type.\ntype %s string\n\nconst (\n", name, e.Schema, e.Name, name)
+		for i, l := range e.Labels {
+			label := exported(l)
+			if label == "" {
+				label = fmt.Sprintf("Value%d", i)
+			}
+			fmt.Fprintf(w, "\t%s%s %s = %q\n", name, label, name, l)
+		}
+		fmt.Fprintln(w, ")")
+	}
+}
+
+func (g *goGen) table(w io.Writer, t Table) {
+	name := exported(singular(t.Name))
+	fmt.Fprintf(w, "\n// %s is a row of the %s.%s table.\ntype %s struct {\n", name, t.Schema, t.Name, name)
+	for _, c := range t.Columns {
+		if c.Comment != "" {
+			for _, line := range strings.Split(strings.TrimSpace(c.Comment), "\n") {
+				fmt.Fprintf(w, "\t// %s\n", strings.TrimSpace(line))
+			}
+		}
+		fmt.Fprintf(w, "\t%s %s %s\n", exported(c.Name), g.goType(c), goRawString(fmt.Sprintf("db:%q", c.Name)))
+	}
+	fmt.Fprintln(w, "}")
+
+	if g.opts.CRUD {
+		g.crud(w, t, name)
+	}
+}
+
+var typeModifiers = regexp.MustCompile(`\(\d+(,\s*\d+)?\)`)
+
+// goType maps a column's postgres type to the Go type it scans into.
+func (g *goGen) goType(c postgres.TableColumn) string {
+	typ := strings.TrimSpace(typeModifiers.ReplaceAllString(c.Type, ""))
+	if strings.HasSuffix(typ, "[]") {
+		g.imports["github.com/lib/pq"] = true
+		return arrayType(strings.TrimSuffix(typ, "[]"))
+	}
+
+	if e, ok := g.enums[strings.Replace(typ, `"`, "", -1)]; ok {
+		name := g.enumName(e)
+		g.used[name] = e
+		if c.NotNull {
+			return name
+		}
+		return "*" + name
+	}
+
+	var base, null string
+	switch typ {
+	case "smallint":
+		base, null = "int16", "sql.NullInt16"
+	case "integer":
+		base, null = "int32", "sql.NullInt32"
+	case "bigint":
+		base, null = "int64", "sql.NullInt64"
+	case "real":
+		base, null = "float32", "sql.NullFloat64"
+	case "double precision":
+		base, null = "float64", "sql.NullFloat64"
+	case "boolean":
+		base, null = "bool", "sql.NullBool"
+	case "date", "timestamp without time zone", "timestamp with time zone":
+		g.imports["time"] = true
+		base, null = "time.Time", "sql.NullTime"
+	case "json", "jsonb":
+		g.imports["encoding/json"] = true
+		if c.NotNull {
+			return "json.RawMessage"
+		}
+		return "*json.RawMessage"
+	case "bytea":
+		// a NULL scans into a nil slice, so it needs no wrapper
+		return "[]byte"
+	default:
+		// text, uuid, numeric and anything else without an exact Go
+		// counterpart is kept as its text representation.
+		base, null = "string", "sql.NullString"
+	}
+
+	switch {
+	case c.NotNull:
+		return base
+	case g.opts.Nullable == NullablePointer:
+		return "*" + base
+	default:
+		g.imports["database/sql"] = true
+		return null
+	}
+}
+
+func arrayType(elem string) string {
+	switch elem {
+	case "smallint", "integer":
+		return "pq.Int32Array"
+	case "bigint":
+		return "pq.Int64Array"
+	case "real":
+		return "pq.Float32Array"
+	case "double precision":
+		return "pq.Float64Array"
+	case "boolean":
+		return "pq.BoolArray"
+	case "bytea":
+		return "pq.ByteaArray"
+	default:
+		return "pq.StringArray"
+	}
+}
+
+// crud writes the query functions of a table. Get, update and delete are
+// only written for tables with a primary key.
+func (g *goGen) crud(w io.Writer, t Table, name string) {
+	g.imports["context"] = true
+	g.imports["github.com/jmoiron/sqlx"] = true
+
+	var (
+		all, keys, inserts, updates []postgres.TableColumn
+		recv                        = receiver(name)
+	)
+	for _, c := range t.Columns {
+		all = append(all, c)
+		if c.PrimaryKey {
+			keys = append(keys, c)
+			continue
+		}
+		if !c.Generated {
+			updates = append(updates, c)
+		}
+	}
+	for _, c := range t.Columns {
+		if !c.Generated && !strings.HasPrefix(c.Default, "nextval(") {
+			inserts = append(inserts, c)
+		}
+	}
+
+	table := quoteIdent(t.Schema) + "." + quoteIdent(t.Name)
+	columns := columnNames(all)
+	plural := exported(t.Name)
+	if plural == name {
+		plural += "List"
+	}
+
+	fmt.Fprintf(w, "\nconst %sColumns = %s\n", unexported(name), goRawString(columns))
+
+	fmt.Fprintf(w, `
+// List%s returns up to limit rows of %s.
+func List%s(ctx context.Context, db sqlx.QueryerContext, limit int) ([]%s, error) {
+	var rows []%s
+	err := sqlx.SelectContext(ctx, db, &rows, "SELECT "+%sColumns+%s, limit)
+	return rows, err
+}
+`, plural, t.Name, plural, name, name, unexported(name), strconv.Quote(" FROM "+table+" LIMIT $1"))
+
+	if len(inserts) > 0 {
+		var args []string
+		for _, c := range inserts {
+			args = append(args, recv+"."+exported(c.Name))
+		}
+		fmt.Fprintf(w, `
+// Insert%s inserts %s and returns the row as stored, with the values
+// filled in by the database.
+func Insert%s(ctx context.Context, db sqlx.QueryerContext, %s %s) (%s, error) {
+	var row %s
+	err := sqlx.GetContext(ctx, db, &row, %s+%sColumns, %s)
+	return row, err
+}
+`, name, recv, name, recv, name, name, name,
+			strconv.Quote("INSERT INTO "+table+" ("+columnNames(inserts)+") VALUES ("+placeholders(1, len(inserts))+") RETURNING "),
+			unexported(name), strings.Join(args, ", "))
+	}
+
+	if len(keys) == 0 {
+		return
+	}
+
+	var (
+		keyParams, keyArgs, keyWhere []string
+	)
+	for i, c := range keys {
+		param := unexported(c.Name)
+		if generatedLocals[param] {
+			param += "Key"
+		}
+		keyParams = append(keyParams, param+" "+g.goType(c))
+		keyArgs = append(keyArgs, param)
+		keyWhere = append(keyWhere, fmt.Sprintf("%s = $%d", quoteIdent(c.Name), i+1))
+	}
+	where := strings.Join(keyWhere, " AND ")
+
+	fmt.Fprintf(w, `
+// Get%s returns the row with the given primary key, or sql.ErrNoRows.
+func Get%s(ctx context.Context, db sqlx.QueryerContext, %s) (%s, error) {
+	var row %s
+	err := sqlx.GetContext(ctx, db, &row, "SELECT "+%sColumns+%s, %s)
+	return row, err
+}
+`, name, name, strings.Join(keyParams, ", "), name, name, unexported(name), strconv.Quote(" FROM "+table+" WHERE "+where), strings.Join(keyArgs, ", "))
+
+	if len(updates) > 0 {
+		var sets, args []string
+		for i, c := range updates {
+			sets = append(sets, fmt.Sprintf("%s = $%d", quoteIdent(c.Name), i+1))
+			args = append(args, recv+"."+exported(c.Name))
+		}
+		var updateWhere []string
+		for i, c := range keys {
+			updateWhere = append(updateWhere, fmt.Sprintf("%s = $%d", quoteIdent(c.Name), len(updates)+i+1))
+			args = append(args, recv+"."+exported(c.Name))
+		}
+		fmt.Fprintf(w, `
+// Update%s writes every column of %s to the row with its primary key.
+func Update%s(ctx context.Context, db sqlx.ExecerContext, %s %s) error {
+	_, err := db.ExecContext(ctx, %s, %s)
+	return err
+}
+`, name, recv, name, recv, name,
+			strconv.Quote("UPDATE "+table+" SET "+strings.Join(sets, ", ")+" WHERE "+strings.Join(updateWhere, " AND ")),
+			strings.Join(args, ", "))
+	}
+
+	fmt.Fprintf(w, `
+// Delete%s deletes the row with the given primary key.
+func Delete%s(ctx context.Context, db sqlx.ExecerContext, %s) error {
+	_, err := db.ExecContext(ctx, %s, %s)
+	return err
+}
+`, name, name, strings.Join(keyParams, ", "), strconv.Quote("DELETE FROM "+table+" WHERE "+where), strings.Join(keyArgs, ", "))
+}
+
+func columnNames(cols []postgres.TableColumn) string {
+	var names []string
+	for _, c := range cols {
+		names = append(names, quoteIdent(c.Name))
+	}
+	return strings.Join(names, ", ")
+}
+
+func placeholders(from, n int) string {
+	var ps []string
+	for i := 0; i < n; i++ {
+		ps = append(ps, fmt.Sprintf("$%d", from+i))
+	}
+	return strings.Join(ps, ", ")
+}
+
+// generatedLocals are the parameters and variables of the generated
+// functions, key parameters with these names are renamed.
+var generatedLocals = map[string]bool{"ctx": true, "db": true, "row": true, "rows": true, "err": true}
+
+var simpleIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// reservedKeywords are the postgres keywords that cannot be used as column
+// or table names without quotes.
+var reservedKeywords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true,
+	"as": true, "asc": true, "asymmetric": true, "authorization": true, "binary": true,
+	"both": true, "case": true, "cast": true, "check": true, "collate": true, "collation": true,
+	"column": true, "concurrently": true, "constraint": true, "create": true, "cross": true,
+	"current_catalog": true, "current_date": true, "current_role": true, "current_schema": true,
+	"current_time": true, "current_timestamp": true, "current_user": true, "default": true,
+	"deferrable": true, "desc": true, "distinct": true, "do": true, "else": true, "end": true,
+	"except": true, "false": true, "fetch": true, "for": true, "foreign": true, "freeze": true,
+	"from": true, "full": true, "grant": true, "group": true, "having": true, "ilike": true,
+	"in": true, "initially": true, "inner": true, "intersect": true, "into": true, "is": true,
+	"isnull": true, "join": true, "lateral": true, "leading": true, "left": true, "like": true,
+	"limit": true, "localtime": true, "localtimestamp": true, "natural": true, "not": true,
+	"notnull": true, "null": true, "offset": true, "on": true, "only": true, "or": true,
+	"order": true, "outer": true, "overlaps": true, "placing": true, "primary": true,
+	"references": true, "returning": true, "right": true, "select": true, "session_user": true,
+	"similar": true, "some": true, "symmetric": true, "system_user": true, "table": true,
+	"tablesample": true, "then": true, "to": true, "trailing": true, "true": true, "union": true,
+	"unique": true, "user": true, "using": true, "variadic": true, "verbose": true, "when": true,
+	"where": true, "window": true, "with": true,
+}
+
+// quoteIdent quotes an identifier only when postgres would need it to be,
+// keeping the generated queries readable.
+func quoteIdent(s string) string {
+	if simpleIdent.MatchString(s) && !reservedKeywords[s] {
+		return s
+	}
+	return pq.QuoteIdentifier(s)
+}
+
+// goRawString writes s as a raw string literal, or as an interpreted one when
+// s holds a backquote.
+func goRawString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// initialisms are written in upper case in Go names, following golint.
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "HTML": true,
+	"HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "SQL": true,
+	"SSH": true, "TLS": true, "TTL": true, "UI": true, "URI": true, "URL": true,
+	"UTF8": true, "UUID": true, "XML": true,
+}
+
+// exported turns a snake_case (or otherwise separated) name into an exported
+// Go identifier, e.g. user_id becomes UserID.
+func exported(s string) string {
+	var b strings.Builder
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(word)
+		b.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+	name := b.String()
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		return "X" + name
+	}
+	return name
+}
+
+func unexported(s string) string {
+	name := exported(s)
+	if name == "" {
+		return "v"
+	}
+
+	// lower case a leading initialism as a whole, e.g. IDList becomes idList
+	var prefix string
+	for upper := range initialisms {
+		if len(upper) > len(prefix) && strings.HasPrefix(name, upper) &&
+			(len(name) == len(upper) || !unicode.IsLower([]rune(name[len(upper):])[0])) {
+			prefix = upper
+		}
+	}
+	if prefix == "" {
+		prefix = string([]rune(name)[0])
+	}
+	name = strings.ToLower(prefix) + name[len(prefix):]
+	if isKeyword(name) {
+		return name + "_"
+	}
+	return name
+}
+
+func isKeyword(s string) bool {
+	switch s {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else",
+		"fallthrough", "for", "func", "go", "goto", "if", "import", "interface",
+		"map", "package", "range", "return", "select", "struct", "switch", "type", "var":
+		return true
+	}
+	return false
+}
+
+func receiver(name string) string {
+	return strings.ToLower(name[:1])
+}
+
+// singular makes a best effort at the singular of a plural table name, e.g.
+// orders becomes order and categories becomes category.
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "xes"), strings.HasSuffix(s, "ches"), strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss") && !strings.HasSuffix(s, "us") && !strings.HasSuffix(s, "is"):
+		return s[:len(s)-1]
+	default:
+		return s
+	}
+}
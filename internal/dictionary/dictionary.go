@@ -0,0 +1,165 @@
+// Package dictionary renders a data dictionary of a database's user schemas
+// from a catalog snapshot.
+package dictionary
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+// Format is an output format of the data dictionary.
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	HTML     Format = "html"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{Markdown, HTML}
+
+type (
+	// Dictionary is the documented content of a database.
+	Dictionary struct {
+		Database   string
+		CapturedAt time.Time
+		Schemas    []Schema
+	}
+
+	Schema struct {
+		Name   string
+		Owner  string
+		Tables []Table
+	}
+
+	// Table is everything documented about a single table.
+	Table struct {
+		postgres.TableInfo
+		Columns      []postgres.TableColumn
+		Keys         []postgres.Constraint
+		Indexes      []postgres.Index
+		References   []postgres.ForeignKey
+		ReferencedBy []postgres.ForeignKey
+	}
+)
+
+// Anchor is the link target of the table's section.
+func (t Table) Anchor() string {
+	return tableAnchor(t.Schema, t.Name)
+}
+
+func tableAnchor(schema, name string) string {
+	return anchor(schema + "." + name)
+}
+
+func anchor(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// New builds the dictionary of every table in the snapshot.
+func New(snap *postgres.Snapshot) Dictionary {
+	d := Dictionary{Database: snap.Database, CapturedAt: snap.CapturedAt}
+
+	schemaIdx := make(map[string]int)
+	for _, s := range snap.Schemas {
+		schemaIdx[s.Name] = len(d.Schemas)
+		d.Schemas = append(d.Schemas, Schema{Name: s.Name, Owner: s.Owner})
+	}
+
+	tableIdx := make(map[string][2]int)
+	for _, t := range snap.Tables {
+		si, ok := schemaIdx[t.Schema]
+		if !ok {
+			si = len(d.Schemas)
+			schemaIdx[t.Schema] = si
+			d.Schemas = append(d.Schemas, Schema{Name: t.Schema})
+		}
+		tableIdx[t.Schema+"."+t.Name] = [2]int{si, len(d.Schemas[si].Tables)}
+		d.Schemas[si].Tables = append(d.Schemas[si].Tables, Table{TableInfo: t})
+	}
+	table := func(schema, name string) *Table {
+		idx, ok := tableIdx[schema+"."+name]
+		if !ok {
+			return nil
+		}
+		return &d.Schemas[idx[0]].Tables[idx[1]]
+	}
+
+	for _, c := range snap.Columns {
+		if t := table(c.Schema, c.Table); t != nil {
+			t.Columns = append(t.Columns, c)
+		}
+	}
+	for _, c := range snap.Constraints {
+		if t := table(c.Schema, c.Table); t != nil && c.Type != "check" && c.Type != "foreign key" {
+			t.Keys = append(t.Keys, c)
+		}
+	}
+	for _, idx := range snap.Indexes {
+		if t := table(idx.Schema, idx.Table); t != nil {
+			t.Indexes = append(t.Indexes, idx)
+		}
+	}
+	for _, fk := range snap.ForeignKeys {
+		if t := table(fk.Schema, fk.Table); t != nil {
+			t.References = append(t.References, fk)
+		}
+		if t := table(fk.RefSchema, fk.RefTable); t != nil {
+			t.ReferencedBy = append(t.ReferencedBy, fk)
+		}
+	}
+
+	// schemas without tables have nothing to document
+	schemas := d.Schemas[:0]
+	for _, s := range d.Schemas {
+		if len(s.Tables) == 0 {
+			continue
+		}
+		sort.SliceStable(s.Tables, func(i, j int) bool { return s.Tables[i].Name < s.Tables[j].Name })
+		for i := range s.Tables {
+			t := &s.Tables[i]
+			sort.SliceStable(t.Columns, func(i, j int) bool { return t.Columns[i].Position < t.Columns[j].Position })
+		}
+		schemas = append(schemas, s)
+	}
+	sort.SliceStable(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
+	d.Schemas = schemas
+	return d
+}
+
+// Write renders the dictionary in the given format.
+func Write(w io.Writer, d Dictionary, f Format) error {
+	switch f {
+	case Markdown:
+		return markdownTemplate.Execute(w, d)
+	case HTML:
+		return htmlTemplate.Execute(w, d)
+	default:
+		return fmt.Errorf("unsupported format %q", f)
+	}
+}
+
+func nullable(c postgres.TableColumn) string {
+	if c.NotNull {
+		return "no"
+	}
+	return "yes"
+}
+
+func columnList(cols []string) string {
+	return strings.Join(cols, ", ")
+}
@@ -0,0 +1,100 @@
+package dictionary
+
+import (
+	"html/template"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+var htmlTemplate = template.Must(template.New("html").Funcs(template.FuncMap{
+	"anchor":  tableAnchor,
+	"size":    postgres.FormatSize,
+	"null":    nullable,
+	"columns": columnList,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Data dictionary: {{ .Database }}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; display: flex; color: #24292f; }
+nav { width: 280px; height: 100vh; overflow-y: auto; position: sticky; top: 0; padding: 1em; border-right: 1px solid #d0d7de; box-sizing: border-box; flex-shrink: 0; }
+nav input { width: 100%; padding: .4em; margin-bottom: 1em; box-sizing: border-box; }
+nav ul { list-style: none; padding-left: .5em; margin: 0 0 1em; }
+nav a { text-decoration: none; color: #0969da; }
+main { padding: 1em 2em; overflow-x: auto; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #d0d7de; padding: .3em .6em; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+code { font-size: .9em; }
+.comment { color: #57606a; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<nav>
+<input id="search" type="search" placeholder="search tables and columns" autofocus>
+{{ range .Schemas }}
+<strong>{{ .Name }}</strong>
+<ul>
+{{ range .Tables }}<li data-table="{{ .Anchor }}"><a href="#{{ .Anchor }}">{{ .Name }}</a></li>
+{{ end }}</ul>
+{{ end }}
+</nav>
+<main>
+<h1>Data dictionary: {{ .Database }}</h1>
+<p class="comment">Generated from the catalog captured at {{ .CapturedAt.Format "2006-01-02 15:04:05 MST" }}.</p>
+{{ range .Schemas }}{{ range .Tables }}
+<section id="{{ .Anchor }}">
+<h2>{{ .Schema }}.{{ .Name }}</h2>
+{{ if .Comment }}<p>{{ .Comment }}</p>{{ end }}
+<table>
+<tr><th>Kind</th><td>{{ .Kind }}</td></tr>
+<tr><th>Owner</th><td>{{ .Owner }}</td></tr>
+<tr><th>Estimated rows</th><td>{{ .RowEstimate }}</td></tr>
+<tr><th>Total size</th><td>{{ size .TotalSize }}</td></tr>
+</table>
+<h3>Columns</h3>
+<table>
+<tr><th>#</th><th>Column</th><th>Type</th><th>Nullable</th><th>Default</th><th>Comment</th></tr>
+{{ range .Columns }}<tr><td>{{ .Position }}</td><td><code>{{ .Name }}</code></td><td>{{ .Type }}</td><td>{{ null . }}</td><td><code>{{ .Default }}</code></td><td class="comment">{{ .Comment }}</td></tr>
+{{ end }}</table>
+{{ if .Keys }}<h3>Keys</h3>
+<table>
+<tr><th>Name</th><th>Type</th><th>Columns</th></tr>
+{{ range .Keys }}<tr><td>{{ .Name }}</td><td>{{ .Type }}</td><td>{{ columns .Columns }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ if .Indexes }}<h3>Indexes</h3>
+<table>
+<tr><th>Name</th><th>Definition</th><th>Size</th></tr>
+{{ range .Indexes }}<tr><td>{{ .Name }}</td><td><code>{{ .Definition }}</code></td><td>{{ size .Size }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ if .References }}<h3>References</h3>
+<table>
+<tr><th>Columns</th><th>Table</th><th>Constraint</th></tr>
+{{ range .References }}<tr><td>{{ columns .Columns }}</td><td><a href="#{{ anchor .RefSchema .RefTable }}">{{ .RefSchema }}.{{ .RefTable }}</a> ({{ columns .RefColumns }})</td><td>{{ .Name }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ if .ReferencedBy }}<h3>Referenced by</h3>
+<table>
+<tr><th>Table</th><th>Columns</th><th>Constraint</th></tr>
+{{ range .ReferencedBy }}<tr><td><a href="#{{ anchor .Schema .Table }}">{{ .Schema }}.{{ .Table }}</a></td><td>{{ columns .Columns }}</td><td>{{ .Name }}</td></tr>
+{{ end }}</table>
+{{ end }}</section>
+{{ end }}{{ end }}
+</main>
+<script>
+document.getElementById("search").addEventListener("input", function (e) {
+	var q = e.target.value.toLowerCase().replace(/\s+/g, "");
+	document.querySelectorAll("main section").forEach(function (s) {
+		var match = q === "" || s.textContent.toLowerCase().replace(/\s+/g, "").indexOf(q) >= 0;
+		s.classList.toggle("hidden", !match);
+		var item = document.querySelector('nav li[data-table="' + s.id + '"]');
+		if (item) {
+			item.classList.toggle("hidden", !match);
+		}
+	});
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,86 @@
+package dictionary
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(template.FuncMap{
+	"anchor":  tableAnchor,
+	"cell":    markdownCell,
+	"size":    postgres.FormatSize,
+	"null":    nullable,
+	"columns": columnList,
+}).Parse(`# Data dictionary: {{ .Database }}
+
+Generated from the catalog captured at {{ .CapturedAt.Format "2006-01-02 15:04:05 MST" }}.
+
+## Index
+{{ range .Schemas }}
+### {{ .Name }}
+{{ range .Tables }}
+* [{{ .Schema }}.{{ .Name }}](#{{ .Anchor }}){{ if .Comment }}: {{ cell .Comment }}{{ end }}
+{{- end }}
+{{ end }}
+{{- range .Schemas }}{{ range .Tables }}
+## {{ .Schema }}.{{ .Name }}
+<a id="{{ .Anchor }}"></a>
+
+{{ if .Comment }}{{ .Comment }}
+
+{{ end -}}
+| | |
+|---|---|
+| Kind | {{ .Kind }} |
+| Owner | {{ .Owner }} |
+| Estimated rows | {{ .RowEstimate }} |
+| Total size | {{ size .TotalSize }} |
+
+### Columns
+
+| # | Column | Type | Nullable | Default | Comment |
+|---|---|---|---|---|---|
+{{ range .Columns -}}
+| {{ .Position }} | {{ cell .Name }} | {{ cell .Type }} | {{ null . }} | {{ cell .Default }} | {{ cell .Comment }} |
+{{ end }}
+{{- if .Keys }}
+### Keys
+
+| Name | Type | Columns |
+|---|---|---|
+{{ range .Keys -}}
+| {{ cell .Name }} | {{ .Type }} | {{ cell (columns .Columns) }} |
+{{ end }}{{ end }}
+{{- if .Indexes }}
+### Indexes
+
+| Name | Definition | Size |
+|---|---|---|
+{{ range .Indexes -}}
+| {{ cell .Name }} | {{ cell .Definition }} | {{ size .Size }} |
+{{ end }}{{ end }}
+{{- if .References }}
+### References
+
+| Columns | Table | Constraint |
+|---|---|---|
+{{ range .References -}}
+| {{ cell (columns .Columns) }} | [{{ .RefSchema }}.{{ .RefTable }}](#{{ anchor .RefSchema .RefTable }}) ({{ cell (columns .RefColumns) }}) | {{ cell .Name }} |
+{{ end }}{{ end }}
+{{- if .ReferencedBy }}
+### Referenced by
+
+| Table | Columns | Constraint |
+|---|---|---|
+{{ range .ReferencedBy -}}
+| [{{ .Schema }}.{{ .Table }}](#{{ anchor .Schema .Table }}) | {{ cell (columns .Columns) }} | {{ cell .Name }} |
+{{ end }}{{ end }}
+{{- end }}{{ end }}`))
+
+// markdownCell makes s safe to put in a table cell.
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", `\|`, -1)
+	return strings.Replace(strings.TrimSpace(s), "\n", "<br>", -1)
+}
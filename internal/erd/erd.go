@@ -0,0 +1,297 @@
+// Package erd renders entity-relationship diagrams of tables and the foreign
+// keys between them as Mermaid, Graphviz DOT or PlantUML text.
+package erd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+type Format string
+
+const (
+	Mermaid  Format = "mermaid"
+	DOT      Format = "dot"
+	PlantUML Format = "plantuml"
+)
+
+// Formats lists every supported output format.
+var Formats = []Format{Mermaid, DOT, PlantUML}
+
+type (
+	Diagram struct {
+		Tables    []Table
+		Relations []Relation
+	}
+
+	Table struct {
+		Schema  string
+		Name    string
+		Columns []Column
+	}
+
+	Column struct {
+		Name       string
+		Type       string
+		NotNull    bool
+		PrimaryKey bool
+		ForeignKey bool
+		Unique     bool
+	}
+
+	// Relation is a foreign key from the Child table to the Parent table.
+	// Optional is set when the key's columns are nullable, so a child row need
+	// not have a parent. OneToOne is set when the key's columns are unique, so
+	// a parent has at most one child.
+	Relation struct {
+		Name     string
+		Parent   string
+		Child    string
+		Optional bool
+		OneToOne bool
+	}
+)
+
+// New builds a diagram from the columns and foreign keys of a schema. When
+// tables is not empty only those tables, and the relations between them, are
+// part of the diagram.
+func New(cols []postgres.TableColumn, keys []postgres.ForeignKey, tables []string) Diagram {
+	include := make(map[string]bool)
+	for _, t := range tables {
+		include[t] = true
+	}
+	keep := func(schema, table string) bool {
+		return len(include) == 0 || include[table] || include[schema+"."+table]
+	}
+
+	fkCols := make(map[string]bool)
+	for _, fk := range keys {
+		for _, c := range fk.Columns {
+			fkCols[fk.Schema+"."+fk.Table+"."+c] = true
+		}
+	}
+
+	var d Diagram
+	index := make(map[string]int)
+	for _, c := range cols {
+		if !keep(c.Schema, c.Table) {
+			continue
+		}
+		name := c.Schema + "." + c.Table
+		i, ok := index[name]
+		if !ok {
+			i = len(d.Tables)
+			index[name] = i
+			d.Tables = append(d.Tables, Table{Schema: c.Schema, Name: c.Table})
+		}
+		d.Tables[i].Columns = append(d.Tables[i].Columns, Column{
+			Name:       c.Name,
+			Type:       c.Type,
+			NotNull:    c.NotNull,
+			PrimaryKey: c.PrimaryKey,
+			ForeignKey: fkCols[name+"."+c.Name],
+			Unique:     c.Unique,
+		})
+	}
+
+	for _, fk := range keys {
+		child, parent := fk.Schema+"."+fk.Table, fk.RefSchema+"."+fk.RefTable
+		ci, ok := index[child]
+		if _, pok := index[parent]; !ok || !pok {
+			continue
+		}
+		rel := Relation{Name: fk.Name, Parent: parent, Child: child}
+		for _, col := range d.Tables[ci].Columns {
+			for _, fkCol := range fk.Columns {
+				if col.Name != fkCol {
+					continue
+				}
+				if !col.NotNull {
+					rel.Optional = true
+				}
+				if len(fk.Columns) == 1 && (col.Unique || col.PrimaryKey && pkSize(d.Tables[ci]) == 1) {
+					rel.OneToOne = true
+				}
+			}
+		}
+		d.Relations = append(d.Relations, rel)
+	}
+
+	sort.Slice(d.Tables, func(i, j int) bool {
+		return d.Tables[i].Schema+"."+d.Tables[i].Name < d.Tables[j].Schema+"."+d.Tables[j].Name
+	})
+	return d
+}
+
+func pkSize(t Table) int {
+	var n int
+	for _, c := range t.Columns {
+		if c.PrimaryKey {
+			n++
+		}
+	}
+	return n
+}
+
+// Write renders the diagram in the given format.
+func Write(w io.Writer, d Diagram, f Format) error {
+	switch f {
+	case Mermaid:
+		return writeMermaid(w, d)
+	case DOT:
+		return writeDOT(w, d)
+	case PlantUML:
+		return writePlantUML(w, d)
+	default:
+		return fmt.Errorf("unsupported diagram format %q", f)
+	}
+}
+
+// id turns a qualified table name into an identifier every format accepts.
+func id(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func keyMarks(c Column) []string {
+	var marks []string
+	if c.PrimaryKey {
+		marks = append(marks, "PK")
+	}
+	if c.ForeignKey {
+		marks = append(marks, "FK")
+	}
+	if c.Unique && !c.PrimaryKey {
+		marks = append(marks, "UK")
+	}
+	return marks
+}
+
+// crowsFoot returns the parent and child ends of a relation in the crow's
+// foot notation shared by Mermaid and PlantUML.
+func crowsFoot(r Relation) (string, string) {
+	parent, child := "||", "o{"
+	if r.Optional {
+		parent = "|o"
+	}
+	if r.OneToOne {
+		child = "o|"
+	}
+	return parent, child
+}
+
+func writeMermaid(w io.Writer, d Diagram) error {
+	ew := &errWriter{w: w}
+	ew.printf("erDiagram\n")
+	for _, t := range d.Tables {
+		ew.printf("    %s {\n", id(t.Schema+"."+t.Name))
+		for _, c := range t.Columns {
+			typ := strings.NewReplacer(" ", "_", ",", "-").Replace(c.Type)
+			ew.printf("        %s %s", typ, id(c.Name))
+			if marks := keyMarks(c); len(marks) > 0 {
+				ew.printf(" %s", strings.Join(marks, ", "))
+			}
+			ew.printf("\n")
+		}
+		ew.printf("    }\n")
+	}
+	for _, r := range d.Relations {
+		parent, child := crowsFoot(r)
+		ew.printf("    %s %s--%s %s : %q\n", id(r.Parent), parent, child, id(r.Child), r.Name)
+	}
+	return ew.err
+}
+
+func writePlantUML(w io.Writer, d Diagram) error {
+	ew := &errWriter{w: w}
+	ew.printf("@startuml\nhide circle\nskinparam linetype ortho\n\n")
+	for _, t := range d.Tables {
+		ew.printf("entity %q as %s {\n", t.Schema+"."+t.Name, id(t.Schema+"."+t.Name))
+		var pk, rest []Column
+		for _, c := range t.Columns {
+			if c.PrimaryKey {
+				pk = append(pk, c)
+			} else {
+				rest = append(rest, c)
+			}
+		}
+		line := func(c Column) {
+			required := "  "
+			if c.NotNull {
+				required = "* "
+			}
+			ew.printf("  %s%s : %s", required, c.Name, c.Type)
+			for _, m := range keyMarks(c) {
+				ew.printf(" <<%s>>", m)
+			}
+			ew.printf("\n")
+		}
+		for _, c := range pk {
+			line(c)
+		}
+		ew.printf("  --\n")
+		for _, c := range rest {
+			line(c)
+		}
+		ew.printf("}\n\n")
+	}
+	for _, r := range d.Relations {
+		parent, child := crowsFoot(r)
+		ew.printf("%s %s--%s %s : %s\n", id(r.Parent), parent, child, id(r.Child), r.Name)
+	}
+	ew.printf("@enduml\n")
+	return ew.err
+}
+
+func writeDOT(w io.Writer, d Diagram) error {
+	esc := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+	ew := &errWriter{w: w}
+	ew.printf("digraph erd {\n    rankdir=LR;\n    node [shape=plain fontname=\"Helvetica\"];\n    edge [dir=both fontsize=10];\n\n")
+	for _, t := range d.Tables {
+		ew.printf("    %s [label=<\n        <table border=\"0\" cellborder=\"1\" cellspacing=\"0\">\n", id(t.Schema+"."+t.Name))
+		ew.printf("        <tr><td colspan=\"3\" bgcolor=\"lightgrey\"><b>%s</b></td></tr>\n", esc.Replace(t.Schema+"."+t.Name))
+		for _, c := range t.Columns {
+			name := esc.Replace(c.Name)
+			if c.NotNull {
+				name = "<b>" + name + "</b>"
+			}
+			ew.printf("        <tr><td align=\"left\" port=\"%s\">%s</td><td align=\"left\">%s</td><td>%s</td></tr>\n",
+				id(c.Name), name, esc.Replace(c.Type), strings.Join(keyMarks(c), " "))
+		}
+		ew.printf("        </table>>];\n")
+	}
+	ew.printf("\n")
+	for _, r := range d.Relations {
+		head, tail := "tee", "crow"
+		if r.Optional {
+			head = "teeodot"
+		}
+		if r.OneToOne {
+			tail = "teeodot"
+		}
+		ew.printf("    %s -> %s [label=%q arrowhead=%s arrowtail=%s];\n", id(r.Child), id(r.Parent), r.Name, head, tail)
+	}
+	ew.printf("}\n")
+	return ew.err
+}
+
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) printf(format string, args ...interface{}) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, format, args...)
+}
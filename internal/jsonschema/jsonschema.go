@@ -0,0 +1,401 @@
+// Package jsonschema describes tables and composite types as JSON Schema
+// documents and OpenAPI components.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+// Format is an output format of the exporter.
+type Format string
+
+const (
+	// JSONSchema writes a JSON Schema 2020-12 document holding every table
+	// and type under $defs.
+	JSONSchema Format = "jsonschema"
+	// OpenAPI writes an OpenAPI 3.1 document holding every table and type
+	// under components.schemas, ready to be merged into an API description.
+	OpenAPI Format = "openapi"
+)
+
+// Schema is the subset of JSON Schema the exporter produces. Type is either
+// a single type name or, for nullable values, a list including "null".
+type Schema struct {
+	Schema           string             `json:"$schema,omitempty"`
+	Ref              string             `json:"$ref,omitempty"`
+	Title            string             `json:"title,omitempty"`
+	Description      string             `json:"description,omitempty"`
+	Type             interface{}        `json:"type,omitempty"`
+	Format           string             `json:"format,omitempty"`
+	ContentEncoding  string             `json:"contentEncoding,omitempty"`
+	Enum             []interface{}      `json:"enum,omitempty"`
+	Minimum          *float64           `json:"minimum,omitempty"`
+	Maximum          *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64           `json:"exclusiveMaximum,omitempty"`
+	MaxLength        *int               `json:"maxLength,omitempty"`
+	ReadOnly         bool               `json:"readOnly,omitempty"`
+	Items            *Schema            `json:"items,omitempty"`
+	Properties       map[string]*Schema `json:"properties,omitempty"`
+	Required         []string           `json:"required,omitempty"`
+	AnyOf            []*Schema          `json:"anyOf,omitempty"`
+	Defs             map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Write exports the tables of schema in the snapshot, or only the named ones
+// when tables is not empty, along with every composite type in the
+// snapshot.
+func Write(w io.Writer, snap *postgres.Snapshot, schema string, tables []string, f Format) error {
+	var refPrefix string
+	switch f {
+	case JSONSchema:
+		refPrefix = "#/$defs/"
+	case OpenAPI:
+		refPrefix = "#/components/schemas/"
+	default:
+		return fmt.Errorf("unsupported format %q", f)
+	}
+
+	defs, err := Definitions(snap, schema, tables, refPrefix)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	if f == JSONSchema {
+		doc = Schema{
+			Schema: "https://json-schema.org/draft/2020-12/schema",
+			Title:  snap.Database,
+			Defs:   defs,
+		}
+	} else {
+		doc = map[string]interface{}{
+			"openapi": "3.1.0",
+			"info": map[string]string{
+				"title":   snap.Database,
+				"version": snap.CapturedAt.Format("2006-01-02"),
+			},
+			"components": map[string]interface{}{
+				"schemas": defs,
+			},
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Definitions returns a schema for every selected table and every composite
+// type, keyed by name. Columns of a composite type refer to its definition
+// with refPrefix followed by the name.
+func Definitions(snap *postgres.Snapshot, schema string, tables []string, refPrefix string) (map[string]*Schema, error) {
+	e := exporter{
+		refPrefix: refPrefix,
+		types:     make(map[string]postgres.Type),
+		checks:    make(map[string][]string),
+	}
+	for _, t := range snap.Types {
+		e.types[t.Schema+"."+t.Name] = t
+		if _, ok := e.types[t.Name]; !ok || t.Schema == "public" {
+			e.types[t.Name] = t
+		}
+	}
+	for _, c := range snap.Constraints {
+		if c.Type == "check" && len(c.Columns) == 1 {
+			key := c.Schema + "." + c.Table + "." + c.Columns[0]
+			e.checks[key] = append(e.checks[key], c.Definition)
+		}
+	}
+
+	selected := make(map[string]bool)
+	for _, t := range tables {
+		selected[t] = true
+	}
+
+	defs := make(map[string]*Schema)
+	for _, t := range snap.Tables {
+		if t.Schema != schema || (len(selected) > 0 && !selected[t.Name]) {
+			continue
+		}
+		delete(selected, t.Name)
+
+		s := &Schema{
+			Title:       t.Name,
+			Description: t.Comment,
+			Type:        "object",
+			Properties:  make(map[string]*Schema),
+		}
+		for _, c := range snap.Columns {
+			if c.Schema != t.Schema || c.Table != t.Name {
+				continue
+			}
+			s.Properties[c.Name] = e.column(c)
+			if c.NotNull {
+				s.Required = append(s.Required, c.Name)
+			}
+		}
+		defs[e.name(t.Schema, t.Name)] = s
+	}
+	for name := range selected {
+		return nil, fmt.Errorf("table %q not found in schema %q", name, schema)
+	}
+
+	for _, t := range snap.Types {
+		if t.Kind != "composite" {
+			continue
+		}
+		s := &Schema{
+			Title:       t.Name,
+			Description: t.Description,
+			Type:        "object",
+			Properties:  make(map[string]*Schema),
+		}
+		for _, a := range t.Attributes {
+			parts := strings.SplitN(a, " ", 2)
+			if len(parts) == 2 {
+				// attributes of a composite type can always be null
+				s.Properties[parts[0]] = e.typeSchema(parts[1], true)
+			}
+		}
+		defs[e.name(t.Schema, t.Name)] = s
+	}
+	return defs, nil
+}
+
+type exporter struct {
+	refPrefix string
+	types     map[string]postgres.Type
+	checks    map[string][]string
+}
+
+// name is the key of a definition, objects outside public keep their schema
+// to stay unique.
+func (e exporter) name(schema, name string) string {
+	if schema == "public" {
+		return name
+	}
+	return schema + "." + name
+}
+
+func (e exporter) column(c postgres.TableColumn) *Schema {
+	s := e.typeSchema(c.Type, !c.NotNull)
+	s.Description = c.Comment
+	s.ReadOnly = c.Generated
+	for _, check := range e.checks[c.Schema+"."+c.Table+"."+c.Name] {
+		applyCheck(s, c.Name, check, !c.NotNull)
+	}
+	return s
+}
+
+var (
+	typeModifiers = regexp.MustCompile(`\((\d+)(,\s*\d+)?\)`)
+	numericTypes  = map[string][2]string{
+		"smallint":         {"integer", "int32"},
+		"integer":          {"integer", "int32"},
+		"bigint":           {"integer", "int64"},
+		"real":             {"number", "float"},
+		"double precision": {"number", "double"},
+		"numeric":          {"number", ""},
+	}
+	stringFormats = map[string]string{
+		"uuid":                        "uuid",
+		"date":                        "date",
+		"timestamp without time zone": "date-time",
+		"timestamp with time zone":    "date-time",
+		"time without time zone":      "time",
+		"time with time zone":         "time",
+	}
+)
+
+// typeSchema maps a type as printed by format_type to its schema.
+func (e exporter) typeSchema(pgType string, nullable bool) *Schema {
+	pgType = strings.TrimSpace(pgType)
+	if strings.HasSuffix(pgType, "[]") {
+		return withType(&Schema{Items: e.typeSchema(strings.TrimSuffix(pgType, "[]"), true)}, "array", nullable)
+	}
+
+	var maxLength *int
+	if m := typeModifiers.FindStringSubmatch(pgType); m != nil && m[2] == "" {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			maxLength = &n
+		}
+	}
+	base := strings.TrimSpace(typeModifiers.ReplaceAllString(pgType, ""))
+
+	if t, ok := e.types[strings.Replace(base, `"`, "", -1)]; ok {
+		switch t.Kind {
+		case "enum":
+			s := withType(&Schema{}, "string", nullable)
+			for _, l := range t.Labels {
+				s.Enum = append(s.Enum, l)
+			}
+			if nullable {
+				s.Enum = append(s.Enum, nil)
+			}
+			return s
+		case "composite":
+			ref := &Schema{Ref: e.refPrefix + e.name(t.Schema, t.Name)}
+			if !nullable {
+				return ref
+			}
+			return &Schema{AnyOf: []*Schema{ref, {Type: "null"}}}
+		case "domain":
+			return e.typeSchema(t.BaseType, nullable && !t.NotNull)
+		}
+	}
+
+	switch {
+	case base == "json" || base == "jsonb":
+		// any JSON value, null included
+		return &Schema{}
+	case base == "boolean":
+		return withType(&Schema{}, "boolean", nullable)
+	case base == "bytea":
+		return withType(&Schema{ContentEncoding: "base64"}, "string", nullable)
+	case numericTypes[base][0] != "":
+		return withType(&Schema{Format: numericTypes[base][1]}, numericTypes[base][0], nullable)
+	case base == "character varying" || base == "character":
+		return withType(&Schema{MaxLength: maxLength}, "string", nullable)
+	default:
+		return withType(&Schema{Format: stringFormats[base]}, "string", nullable)
+	}
+}
+
+func withType(s *Schema, typ string, nullable bool) *Schema {
+	if nullable {
+		s.Type = []string{typ, "null"}
+	} else {
+		s.Type = typ
+	}
+	return s
+}
+
+var (
+	checkElement    = regexp.MustCompile(`^\(*(?:'((?:[^']|'')*)'|(-?\d+(?:\.\d+)?))`)
+	checkComparison = regexp.MustCompile(`(>=|<=|>|<)\s*\(?'?(-?\d+(?:\.\d+)?)`)
+	checkLength     = regexp.MustCompile(`(?:char_length|length)\(`)
+)
+
+// applyCheck narrows s with what can be read from a check constraint on the
+// column: a list of allowed values becomes an enum and comparisons with
+// constants become bounds. Anything more involved is left alone.
+func applyCheck(s *Schema, column, check string, nullable bool) {
+	if strings.Contains(check, " OR ") {
+		return
+	}
+
+	if strings.Contains(check, "= ANY (") && strings.Contains(check, "ARRAY[") {
+		var values []interface{}
+		for _, el := range arrayElements(check) {
+			m := checkElement.FindStringSubmatch(el)
+			switch {
+			case m == nil:
+				continue
+			case m[2] != "":
+				v, _ := strconv.ParseFloat(m[2], 64)
+				values = append(values, v)
+			case isNumeric(s):
+				// negative numbers are printed as quoted literals.
+				if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+					values = append(values, v)
+				}
+			default:
+				values = append(values, strings.Replace(m[1], "''", "'", -1))
+			}
+		}
+		if len(values) == 0 {
+			return
+		}
+		if nullable {
+			values = append(values, nil)
+		}
+		s.Enum = values
+		return
+	}
+
+	for _, term := range strings.Split(check, " AND ") {
+		if !strings.Contains(term, column) {
+			continue
+		}
+		m := checkComparison.FindStringSubmatch(term)
+		if m == nil {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+
+		if checkLength.MatchString(term) {
+			if n := int(v); m[1] == "<=" {
+				s.MaxLength = &n
+			} else if m[1] == "<" {
+				n--
+				s.MaxLength = &n
+			}
+			continue
+		}
+		switch m[1] {
+		case ">=":
+			s.Minimum = &v
+		case ">":
+			s.ExclusiveMinimum = &v
+		case "<=":
+			s.Maximum = &v
+		case "<":
+			s.ExclusiveMaximum = &v
+		}
+	}
+}
+
+// arrayElements returns the elements of the first ARRAY[...] in check, split
+// on the commas outside of quotes and parentheses.
+func arrayElements(check string) []string {
+	start := strings.Index(check, "ARRAY[")
+	if start < 0 {
+		return nil
+	}
+
+	var (
+		elems  []string
+		from   = start + len("ARRAY[")
+		depth  int
+		quoted bool
+	)
+	for i := from; i < len(check); i++ {
+		switch c := check[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || (c == ']' && depth > 0):
+			depth--
+		case c == ',' && depth == 0:
+			elems = append(elems, strings.TrimSpace(check[from:i]))
+			from = i + 1
+		case c == ']':
+			return append(elems, strings.TrimSpace(check[from:i]))
+		}
+	}
+	return nil
+}
+
+// isNumeric reports whether s describes a number or an integer.
+func isNumeric(s *Schema) bool {
+	switch typ := s.Type.(type) {
+	case string:
+		return typ == "number" || typ == "integer"
+	case []string:
+		return len(typ) > 0 && (typ[0] == "number" || typ[0] == "integer")
+	}
+	return false
+}
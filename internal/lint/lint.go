@@ -0,0 +1,436 @@
+// Package lint checks a catalog for schema design smells, such as tables
+// without a primary key or foreign keys without an index. Rules can be turned
+// off and the naming conventions changed in a configuration file.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+// Level is how serious a finding is. The names follow SARIF.
+type Level string
+
+// Levels from most to least serious.
+const (
+	Error   Level = "error"
+	Warning Level = "warning"
+	Note    Level = "note"
+)
+
+// Finding is a design smell found by a rule, on the table or column it
+// names. Column is empty for findings on a table, Object names indexes and
+// constraints.
+type Finding struct {
+	Rule    string `json:"rule"`
+	Level   Level  `json:"level"`
+	Schema  string `json:"schema"`
+	Table   string `json:"table"`
+	Column  string `json:"column,omitempty"`
+	Object  string `json:"object,omitempty"`
+	Message string `json:"message"`
+}
+
+// Location names what the finding is about as schema.table[.column].
+func (f Finding) Location() string {
+	loc := f.Schema + "." + f.Table
+	switch {
+	case f.Column != "":
+		loc += "." + f.Column
+	case f.Object != "":
+		loc += " " + f.Object
+	}
+	return loc
+}
+
+// Naming holds the regular expressions names have to match. An empty
+// expression accepts any name.
+type Naming struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Index  string `json:"index"`
+}
+
+// Config turns rules on and off by name and sets the naming conventions.
+// Rules not mentioned are on.
+type Config struct {
+	Rules  map[string]bool `json:"rules"`
+	Naming Naming          `json:"naming"`
+}
+
+const snakeCase = `^[a-z_][a-z0-9_]*$`
+
+// DefaultConfig runs every rule and expects snake_case names.
+func DefaultConfig() Config {
+	return Config{
+		Naming: Naming{Table: snakeCase, Column: snakeCase, Index: snakeCase},
+	}
+}
+
+// LoadConfig reads a configuration file over DefaultConfig, so a file that
+// does not exist or leaves out a naming convention keeps the default. An
+// empty naming convention accepts any name.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	for name := range cfg.Rules {
+		if _, ok := ruleByName(name); !ok {
+			return Config{}, fmt.Errorf("reading %s: unknown rule %q", path, name)
+		}
+	}
+	return cfg, nil
+}
+
+func (c Config) enabled(rule string) bool {
+	on, ok := c.Rules[rule]
+	return !ok || on
+}
+
+// Rule is a design check run over a snapshot.
+type Rule struct {
+	Name        string
+	Description string
+	Level       Level
+	check       func(l *linter) []Finding
+}
+
+// Rules are the rules the linter knows, in the order they run.
+var Rules = []Rule{
+	{
+		Name:        "table-without-primary-key",
+		Description: "Tables should have a primary key, so rows can be identified, replicated and referenced.",
+		Level:       Warning,
+		check:       (*linter).tablesWithoutPrimaryKey,
+	},
+	{
+		Name:        "foreign-key-without-index",
+		Description: "Foreign key columns should lead an index, or deletes and updates of the referenced rows scan the whole table.",
+		Level:       Warning,
+		check:       (*linter).foreignKeysWithoutIndex,
+	},
+	{
+		Name:        "inconsistent-id-type",
+		Description: "Columns named id should have the same type in every table.",
+		Level:       Warning,
+		check:       (*linter).inconsistentIDTypes,
+	},
+	{
+		Name:        "timestamp-without-time-zone",
+		Description: "timestamp without time zone loses the offset of the values stored; use timestamptz.",
+		Level:       Warning,
+		check:       (*linter).timestampsWithoutTimeZone,
+	},
+	{
+		Name:        "varchar-length",
+		Description: "varchar(n) only adds a length check that is costly to change; text with a check constraint does the same.",
+		Level:       Note,
+		check:       (*linter).varcharLengths,
+	},
+	{
+		Name:        "nullable-foreign-key",
+		Description: "Foreign key columns without NOT NULL allow rows that reference nothing.",
+		Level:       Note,
+		check:       (*linter).nullableForeignKeys,
+	},
+	{
+		Name:        "naming-convention",
+		Description: "Table, column and index names should match the configured naming conventions.",
+		Level:       Warning,
+		check:       (*linter).namingConventions,
+	},
+}
+
+func ruleByName(name string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+type linter struct {
+	snap    *postgres.Snapshot
+	table   *regexp.Regexp
+	column  *regexp.Regexp
+	index   *regexp.Regexp
+	columns map[string]postgres.TableColumn
+}
+
+// Run runs the enabled rules over the snapshot and returns their findings,
+// by schema, table and rule.
+func Run(snap *postgres.Snapshot, cfg Config) ([]Finding, error) {
+	l := &linter{snap: snap, columns: make(map[string]postgres.TableColumn)}
+	for _, p := range []struct {
+		expr string
+		re   **regexp.Regexp
+	}{
+		{cfg.Naming.Table, &l.table},
+		{cfg.Naming.Column, &l.column},
+		{cfg.Naming.Index, &l.index},
+	} {
+		if p.expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(p.expr)
+		if err != nil {
+			return nil, fmt.Errorf("naming convention %q: %w", p.expr, err)
+		}
+		*p.re = re
+	}
+	for _, c := range snap.Columns {
+		l.columns[c.Schema+"."+c.Table+"."+c.Name] = c
+	}
+
+	var findings []Finding
+	for _, r := range Rules {
+		if !cfg.enabled(r.Name) {
+			continue
+		}
+		for _, f := range r.check(l) {
+			f.Rule, f.Level = r.Name, r.Level
+			findings = append(findings, f)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Schema != b.Schema {
+			return a.Schema < b.Schema
+		}
+		return a.Table < b.Table
+	})
+	return findings, nil
+}
+
+func (l *linter) tablesWithoutPrimaryKey() []Finding {
+	hasPK := make(map[string]bool)
+	for _, c := range l.snap.Constraints {
+		if c.Type == "primary key" {
+			hasPK[c.Schema+"."+c.Table] = true
+		}
+	}
+	var findings []Finding
+	for _, t := range l.snap.Tables {
+		if t.Kind == "foreign table" || hasPK[t.Schema+"."+t.Name] {
+			continue
+		}
+		findings = append(findings, Finding{Schema: t.Schema, Table: t.Name, Message: "table has no primary key"})
+	}
+	return findings
+}
+
+func (l *linter) foreignKeysWithoutIndex() []Finding {
+	indexes := make(map[string][]postgres.Index)
+	for _, idx := range l.snap.Indexes {
+		key := idx.Schema + "." + idx.Table
+		indexes[key] = append(indexes[key], idx)
+	}
+
+	var findings []Finding
+	for _, fk := range l.snap.ForeignKeys {
+		covered := false
+		for _, idx := range indexes[fk.Schema+"."+fk.Table] {
+			if leads(idx.Columns, fk.Columns) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		findings = append(findings, Finding{
+			Schema:  fk.Schema,
+			Table:   fk.Table,
+			Object:  fk.Name,
+			Message: fmt.Sprintf("no index leads with the foreign key columns (%s)", strings.Join(fk.Columns, ", ")),
+		})
+	}
+	return findings
+}
+
+// leads reports whether the first len(cols) index columns are cols, in any
+// order.
+func leads(indexCols, cols []string) bool {
+	if len(indexCols) < len(cols) {
+		return false
+	}
+	want := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		want[c] = true
+	}
+	for _, c := range indexCols[:len(cols)] {
+		if !want[c] {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *linter) inconsistentIDTypes() []Finding {
+	counts := make(map[string]int)
+	var ids []postgres.TableColumn
+	for _, c := range l.snap.Columns {
+		if c.Name == "id" {
+			ids = append(ids, c)
+			counts[c.Type]++
+		}
+	}
+	if len(counts) < 2 {
+		return nil
+	}
+
+	common := ""
+	for typ, n := range counts {
+		if n > counts[common] || (n == counts[common] && typ < common) {
+			common = typ
+		}
+	}
+	var findings []Finding
+	for _, c := range ids {
+		if c.Type == common {
+			continue
+		}
+		findings = append(findings, Finding{
+			Schema:  c.Schema,
+			Table:   c.Table,
+			Column:  c.Name,
+			Message: fmt.Sprintf("id is %s, %d other table(s) use %s", c.Type, counts[common], common),
+		})
+	}
+	return findings
+}
+
+func (l *linter) timestampsWithoutTimeZone() []Finding {
+	var findings []Finding
+	for _, c := range l.snap.Columns {
+		if strings.HasPrefix(c.Type, "timestamp") && strings.HasSuffix(c.Type, "without time zone") {
+			findings = append(findings, Finding{
+				Schema:  c.Schema,
+				Table:   c.Table,
+				Column:  c.Name,
+				Message: c.Type + ", use timestamp with time zone",
+			})
+		}
+	}
+	return findings
+}
+
+func (l *linter) varcharLengths() []Finding {
+	var findings []Finding
+	for _, c := range l.snap.Columns {
+		if strings.HasPrefix(c.Type, "character varying(") {
+			findings = append(findings, Finding{
+				Schema:  c.Schema,
+				Table:   c.Table,
+				Column:  c.Name,
+				Message: c.Type + ", consider text",
+			})
+		}
+	}
+	return findings
+}
+
+func (l *linter) nullableForeignKeys() []Finding {
+	var findings []Finding
+	for _, fk := range l.snap.ForeignKeys {
+		for _, name := range fk.Columns {
+			c, ok := l.columns[fk.Schema+"."+fk.Table+"."+name]
+			if !ok || c.NotNull {
+				continue
+			}
+			findings = append(findings, Finding{
+				Schema:  fk.Schema,
+				Table:   fk.Table,
+				Column:  name,
+				Message: fmt.Sprintf("column of foreign key %s allows NULL", fk.Name),
+			})
+		}
+	}
+	return findings
+}
+
+func (l *linter) namingConventions() []Finding {
+	var findings []Finding
+	if l.table != nil {
+		for _, t := range l.snap.Tables {
+			if !l.table.MatchString(t.Name) {
+				findings = append(findings, Finding{
+					Schema:  t.Schema,
+					Table:   t.Name,
+					Message: fmt.Sprintf("table name does not match %s", l.table),
+				})
+			}
+		}
+	}
+	if l.column != nil {
+		for _, c := range l.snap.Columns {
+			if !l.column.MatchString(c.Name) {
+				findings = append(findings, Finding{
+					Schema:  c.Schema,
+					Table:   c.Table,
+					Column:  c.Name,
+					Message: fmt.Sprintf("column name does not match %s", l.column),
+				})
+			}
+		}
+	}
+	if l.index != nil {
+		for _, idx := range l.snap.Indexes {
+			if !l.index.MatchString(idx.Name) {
+				findings = append(findings, Finding{
+					Schema:  idx.Schema,
+					Table:   idx.Table,
+					Object:  idx.Name,
+					Message: fmt.Sprintf("index name does not match %s", l.index),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// Write renders the findings as text, JSON or SARIF.
+func Write(w io.Writer, findings []Finding, format string) error {
+	switch format {
+	case "text":
+		if len(findings) == 0 {
+			_, err := fmt.Fprintln(w, "no findings")
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, f := range findings {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Level, f.Rule, f.Location(), f.Message)
+		}
+		return tw.Flush()
+	case "json":
+		if findings == nil {
+			findings = []Finding{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(findings)
+	case "sarif":
+		return writeSARIF(w, findings)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
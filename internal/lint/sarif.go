@@ -0,0 +1,100 @@
+package lint
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// The subset of SARIF 2.1.0 the findings are written in. Database objects
+// have no file to point at, so results carry logical locations only.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+
+	sarifRule struct {
+		ID                   string       `json:"id"`
+		ShortDescription     sarifMessage `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level Level `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     Level           `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+
+	sarifLocation struct {
+		LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+	}
+
+	sarifLogicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+		Kind               string `json:"kind"`
+	}
+)
+
+func writeSARIF(w io.Writer, findings []Finding) error {
+	driver := sarifDriver{
+		Name:           "pgkons",
+		InformationURI: "https://github.com/jsteenb2/pgkons",
+	}
+	ruleIndex := make(map[string]int, len(Rules))
+	for i, r := range Rules {
+		rule := sarifRule{ID: r.Name, ShortDescription: sarifMessage{Text: r.Description}}
+		rule.DefaultConfiguration.Level = r.Level
+		driver.Rules = append(driver.Rules, rule)
+		ruleIndex[r.Name] = i
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		loc := sarifLogicalLocation{FullyQualifiedName: f.Schema + "." + f.Table, Kind: "table"}
+		switch {
+		case f.Column != "":
+			loc = sarifLogicalLocation{FullyQualifiedName: loc.FullyQualifiedName + "." + f.Column, Kind: "column"}
+		case f.Object != "":
+			loc = sarifLogicalLocation{FullyQualifiedName: f.Schema + "." + f.Object, Kind: "object"}
+		}
+		results = append(results, sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: ruleIndex[f.Rule],
+			Level:     f.Level,
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{loc}}},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
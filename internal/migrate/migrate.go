@@ -0,0 +1,823 @@
+// Package migrate turns the differences between two catalogs into the SQL
+// statements that bring one database in line with the other.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+	"github.com/jsteenb2/pgkons/internal/schemadiff"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Statement is a single migration step. Statements with an empty SQL only
+// carry a Comment, they flag changes that cannot be migrated automatically.
+type Statement struct {
+	Comment string
+	SQL     string
+}
+
+// Write renders the statements as a SQL script.
+func Write(w io.Writer, stmts []Statement) error {
+	if len(stmts) == 0 {
+		_, err := fmt.Fprintln(w, "-- no differences")
+		return err
+	}
+	for _, s := range stmts {
+		if s.Comment != "" {
+			if _, err := fmt.Fprintf(w, "-- %s\n", s.Comment); err != nil {
+				return err
+			}
+		}
+		if s.SQL != "" {
+			if _, err := fmt.Fprintf(w, "%s;\n", s.SQL); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Generate returns the statements that turn the database captured in from
+// into the one captured in to. Statements are ordered so dependencies are
+// respected: views are dropped before the columns under them change and are
+// recreated afterwards, types, functions and sequences are created before the
+// tables using them, and foreign keys are dropped before the keys they
+// reference.
+func Generate(from, to *postgres.Snapshot) []Statement {
+	g := generator{
+		from:    from,
+		to:      to,
+		changes: schemadiff.Compare(from, to),
+	}
+	g.index()
+
+	phases := []func(){
+		g.dropViews,
+		g.dropFunctions,
+		g.dropConstraints,
+		g.dropIndexes,
+		g.createSchemas,
+		g.createTypes,
+		g.createFunctions,
+		g.createTables,
+		g.alterTables,
+		g.alterColumns,
+		g.addConstraints,
+		g.createIndexes,
+		g.createViews,
+		g.dropTables,
+		g.dropTypes,
+		g.dropSchemas,
+	}
+	for _, phase := range phases {
+		phase()
+	}
+	return g.stmts
+}
+
+type generator struct {
+	from, to *postgres.Snapshot
+	changes  []schemadiff.Change
+	stmts    []Statement
+
+	removedTables map[string]bool
+	fromColumns   map[string]postgres.TableColumn
+	toColumns     map[string]postgres.TableColumn
+	constraints   map[string]bool
+	recreateViews map[string]bool
+	seenSequences map[string]bool
+}
+
+func (g *generator) add(format string, args ...interface{}) {
+	g.stmts = append(g.stmts, Statement{SQL: fmt.Sprintf(format, args...)})
+}
+
+func (g *generator) note(comment string) {
+	g.stmts = append(g.stmts, Statement{Comment: comment})
+}
+
+func (g *generator) each(object string, kinds []schemadiff.Kind, fn func(schemadiff.Change)) {
+	for _, c := range g.changes {
+		if c.Object != object {
+			continue
+		}
+		for _, k := range kinds {
+			if c.Kind == k {
+				fn(c)
+				break
+			}
+		}
+	}
+}
+
+var (
+	added   = []schemadiff.Kind{schemadiff.Added}
+	removed = []schemadiff.Kind{schemadiff.Removed}
+	changed = []schemadiff.Kind{schemadiff.Changed}
+	gone    = []schemadiff.Kind{schemadiff.Removed, schemadiff.Changed}
+	fresh   = []schemadiff.Kind{schemadiff.Added, schemadiff.Changed}
+)
+
+func (g *generator) index() {
+	g.removedTables = make(map[string]bool)
+	alteredTables := make(map[string]bool)
+	for _, c := range g.changes {
+		switch {
+		case c.Object == schemadiff.TableObject && c.Kind == schemadiff.Removed:
+			g.removedTables[c.Schema+"."+c.Table] = true
+			alteredTables[c.Schema+"."+c.Table] = true
+		case c.Object == schemadiff.ColumnObject && c.Kind != schemadiff.Added:
+			alteredTables[c.Schema+"."+c.Table] = true
+		}
+	}
+
+	g.fromColumns = make(map[string]postgres.TableColumn)
+	for _, c := range g.from.Columns {
+		g.fromColumns[c.Schema+"."+c.Table+"."+c.Name] = c
+	}
+	g.toColumns = make(map[string]postgres.TableColumn)
+	for _, c := range g.to.Columns {
+		g.toColumns[c.Schema+"."+c.Table+"."+c.Name] = c
+	}
+
+	g.constraints = make(map[string]bool)
+	for _, s := range []*postgres.Snapshot{g.from, g.to} {
+		for _, c := range s.Constraints {
+			g.constraints[c.Schema+"."+c.Table+"."+c.Name] = true
+		}
+	}
+
+	// views over altered tables, and views over those views, have to be
+	// dropped and recreated for the alteration to go through.
+	g.recreateViews = make(map[string]bool)
+	refs := viewRefs(append(append([]postgres.View(nil), g.from.Views...), g.from.MaterializedViews...))
+	for grew := true; grew; {
+		grew = false
+		for view, tables := range refs {
+			if g.recreateViews[view] {
+				continue
+			}
+			for _, t := range tables {
+				if alteredTables[t] || g.recreateViews[t] {
+					g.recreateViews[view] = true
+					grew = true
+					break
+				}
+			}
+		}
+	}
+	g.seenSequences = make(map[string]bool)
+}
+
+func viewRefs(views []postgres.View) map[string][]string {
+	refs := make(map[string][]string)
+	for _, v := range views {
+		key := v.ViewSchema + "." + v.Name
+		if _, ok := refs[key]; !ok {
+			refs[key] = nil
+		}
+		if v.ReferencedTableName != "" {
+			refs[key] = append(refs[key], v.ReferencedTableSchema+"."+v.ReferencedTableName)
+		}
+	}
+	return refs
+}
+
+// viewOrder sorts views so every view comes after the views it references.
+func viewOrder(keys map[string]bool, refs map[string][]string) []string {
+	var (
+		order   []string
+		visited = make(map[string]bool)
+		visit   func(string)
+	)
+	visit = func(k string) {
+		if visited[k] {
+			return
+		}
+		visited[k] = true
+		for _, ref := range refs[k] {
+			if keys[ref] {
+				visit(ref)
+			}
+		}
+		order = append(order, k)
+	}
+	for _, k := range sortedKeys(keys) {
+		visit(k)
+	}
+	return order
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func qualified(schema, name string) string {
+	return pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(name)
+}
+
+func trimStatement(sql string) string {
+	return strings.TrimRight(strings.TrimSpace(sql), ";")
+}
+
+type viewInfo struct {
+	object     string
+	schema     string
+	name       string
+	definition string
+}
+
+func views(s *postgres.Snapshot) map[string]viewInfo {
+	m := make(map[string]viewInfo)
+	for _, v := range s.Views {
+		m[v.ViewSchema+"."+v.Name] = viewInfo{schemadiff.ViewObject, v.ViewSchema, v.Name, v.Definition}
+	}
+	for _, v := range s.MaterializedViews {
+		m[v.ViewSchema+"."+v.Name] = viewInfo{schemadiff.MaterializedViewObject, v.ViewSchema, v.Name, v.Definition}
+	}
+	return m
+}
+
+func (g *generator) dropViews() {
+	drop := make(map[string]bool)
+	for k := range g.recreateViews {
+		drop[k] = true
+	}
+	for _, object := range []string{schemadiff.ViewObject, schemadiff.MaterializedViewObject} {
+		g.each(object, gone, func(c schemadiff.Change) { drop[c.Name] = true })
+	}
+
+	all := views(g.from)
+	refs := viewRefs(append(append([]postgres.View(nil), g.from.Views...), g.from.MaterializedViews...))
+	order := viewOrder(drop, refs)
+	for i := len(order) - 1; i >= 0; i-- {
+		v, ok := all[order[i]]
+		if !ok {
+			continue
+		}
+		kind := "VIEW"
+		if v.object == schemadiff.MaterializedViewObject {
+			kind = "MATERIALIZED VIEW"
+		}
+		g.add("DROP %s %s", kind, qualified(v.schema, v.name))
+	}
+}
+
+func (g *generator) createViews() {
+	create := make(map[string]bool)
+	all := views(g.to)
+	for k := range g.recreateViews {
+		if _, ok := all[k]; ok {
+			create[k] = true
+		}
+	}
+	for _, object := range []string{schemadiff.ViewObject, schemadiff.MaterializedViewObject} {
+		g.each(object, fresh, func(c schemadiff.Change) { create[c.Name] = true })
+	}
+
+	refs := viewRefs(append(append([]postgres.View(nil), g.to.Views...), g.to.MaterializedViews...))
+	for _, k := range viewOrder(create, refs) {
+		v := all[k]
+		kind := "VIEW"
+		if v.object == schemadiff.MaterializedViewObject {
+			kind = "MATERIALIZED VIEW"
+		}
+		g.add("CREATE %s %s AS\n%s", kind, qualified(v.schema, v.name), trimStatement(v.definition))
+	}
+}
+
+func functions(s *postgres.Snapshot) map[string]postgres.Function {
+	m := make(map[string]postgres.Function)
+	for _, f := range s.Functions {
+		m[f.Signature()] = f
+	}
+	return m
+}
+
+// dropFunctions drops removed functions, and changed ones that cannot be
+// replaced in place because their result type or kind changed.
+func (g *generator) dropFunctions() {
+	from, to := functions(g.from), functions(g.to)
+	g.each(schemadiff.FunctionObject, gone, func(c schemadiff.Change) {
+		f := from[c.Name]
+		if c.Kind == schemadiff.Changed && !needsRecreate(f, to[c.Name]) {
+			return
+		}
+		g.add("DROP %s %s(%s)", routineKind(f), qualified(f.Schema, f.Name), dropArguments(f.Arguments))
+	})
+}
+
+func (g *generator) createFunctions() {
+	from, to := functions(g.from), functions(g.to)
+	g.each(schemadiff.FunctionObject, fresh, func(c schemadiff.Change) {
+		f := to[c.Name]
+		if c.Kind == schemadiff.Added || strings.Contains(strings.Join(c.Details, "\n"), "source changed") || needsRecreate(from[c.Name], f) {
+			g.add("%s", trimStatement(f.Source))
+		}
+		if c.Kind == schemadiff.Changed && from[c.Name].Owner != f.Owner {
+			g.add("ALTER %s %s(%s) OWNER TO %s", routineKind(f), qualified(f.Schema, f.Name), dropArguments(f.Arguments), pq.QuoteIdentifier(f.Owner))
+		}
+	})
+}
+
+func needsRecreate(a, b postgres.Function) bool {
+	return a.Result != b.Result || a.Kind != b.Kind || a.Kind == "aggregate"
+}
+
+func routineKind(f postgres.Function) string {
+	switch f.Kind {
+	case "procedure":
+		return "PROCEDURE"
+	case "aggregate":
+		return "AGGREGATE"
+	default:
+		return "FUNCTION"
+	}
+}
+
+// dropArguments strips defaults from an argument list, DROP and ALTER only
+// take the argument types.
+func dropArguments(args string) string {
+	var out []string
+	depth, start := 0, 0
+	split := func(end int) {
+		arg := strings.TrimSpace(args[start:end])
+		if i := strings.Index(strings.ToUpper(arg), " DEFAULT "); i >= 0 {
+			arg = arg[:i]
+		}
+		if arg != "" {
+			out = append(out, arg)
+		}
+	}
+	for i, r := range args {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				split(i)
+				start = i + 1
+			}
+		}
+	}
+	split(len(args))
+	return strings.Join(out, ", ")
+}
+
+func (g *generator) isForeignKey(c schemadiff.Change) bool {
+	def := c.Before
+	if def == "" {
+		def = c.After
+	}
+	return strings.HasPrefix(def, "FOREIGN KEY")
+}
+
+func constraintName(c schemadiff.Change) string {
+	return strings.TrimPrefix(c.Name, c.Schema+"."+c.Table+".")
+}
+
+// dropConstraints drops foreign keys first, so the primary and unique keys
+// they reference can go next.
+func (g *generator) dropConstraints() {
+	for _, fks := range []bool{true, false} {
+		g.each(schemadiff.ConstraintObject, gone, func(c schemadiff.Change) {
+			// the foreign keys of removed tables go too, so the tables can be
+			// dropped in any order.
+			if g.isForeignKey(c) != fks || (!fks && g.removedTables[c.Schema+"."+c.Table]) {
+				return
+			}
+			g.add("ALTER TABLE %s DROP CONSTRAINT %s", qualified(c.Schema, c.Table), pq.QuoteIdentifier(constraintName(c)))
+		})
+	}
+}
+
+// addConstraints adds foreign keys last, once the keys they reference exist.
+func (g *generator) addConstraints() {
+	for _, fks := range []bool{false, true} {
+		g.each(schemadiff.ConstraintObject, fresh, func(c schemadiff.Change) {
+			if g.isForeignKey(c) != fks {
+				return
+			}
+			g.add("ALTER TABLE %s ADD CONSTRAINT %s %s", qualified(c.Schema, c.Table), pq.QuoteIdentifier(constraintName(c)), c.After)
+		})
+	}
+}
+
+// backsConstraint reports whether an index exists to enforce a primary key,
+// unique or exclusion constraint, those come and go with the constraint.
+func (g *generator) backsConstraint(c schemadiff.Change) bool {
+	return g.constraints[c.Schema+"."+c.Table+"."+strings.TrimPrefix(c.Name, c.Schema+".")]
+}
+
+func (g *generator) dropIndexes() {
+	g.each(schemadiff.IndexObject, gone, func(c schemadiff.Change) {
+		if g.backsConstraint(c) || g.removedTables[c.Schema+"."+c.Table] {
+			return
+		}
+		g.add("DROP INDEX %s", qualified(c.Schema, strings.TrimPrefix(c.Name, c.Schema+".")))
+	})
+}
+
+func (g *generator) createIndexes() {
+	g.each(schemadiff.IndexObject, fresh, func(c schemadiff.Change) {
+		if g.backsConstraint(c) {
+			return
+		}
+		g.add("%s", c.After)
+	})
+}
+
+func (g *generator) createSchemas() {
+	g.each(schemadiff.SchemaObject, added, func(c schemadiff.Change) {
+		g.add("CREATE SCHEMA %s", pq.QuoteIdentifier(c.Name))
+	})
+	g.each(schemadiff.SchemaObject, changed, func(c schemadiff.Change) {
+		for _, s := range g.to.Schemas {
+			if s.Name == c.Name {
+				g.add("ALTER SCHEMA %s OWNER TO %s", pq.QuoteIdentifier(s.Name), pq.QuoteIdentifier(s.Owner))
+			}
+		}
+	})
+}
+
+func (g *generator) dropSchemas() {
+	g.each(schemadiff.SchemaObject, removed, func(c schemadiff.Change) {
+		g.add("DROP SCHEMA %s", pq.QuoteIdentifier(c.Name))
+	})
+}
+
+func types(s *postgres.Snapshot) map[string]postgres.Type {
+	m := make(map[string]postgres.Type)
+	for _, t := range s.Types {
+		m[t.Schema+"."+t.Name] = t
+	}
+	return m
+}
+
+func (g *generator) createTypes() {
+	from, to := types(g.from), types(g.to)
+	g.each(schemadiff.TypeObject, added, func(c schemadiff.Change) {
+		g.createType(to[c.Name])
+	})
+	g.each(schemadiff.TypeObject, changed, func(c schemadiff.Change) {
+		a, b := from[c.Name], to[c.Name]
+		if a.Kind != "enum" || b.Kind != "enum" {
+			g.note(fmt.Sprintf("type %s changed and needs a manual migration: %s", c.Name, strings.Join(c.Details, "; ")))
+			return
+		}
+
+		existing := make(map[string]bool)
+		for _, l := range a.Labels {
+			existing[l] = true
+		}
+		for i, l := range b.Labels {
+			if existing[l] {
+				continue
+			}
+			position := ""
+			if i > 0 {
+				position = " AFTER " + pq.QuoteLiteral(b.Labels[i-1])
+			} else if len(a.Labels) > 0 {
+				position = " BEFORE " + pq.QuoteLiteral(a.Labels[0])
+			}
+			g.add("ALTER TYPE %s ADD VALUE %s%s", qualified(b.Schema, b.Name), pq.QuoteLiteral(l), position)
+		}
+
+		wanted := make(map[string]bool)
+		for _, l := range b.Labels {
+			wanted[l] = true
+		}
+		for _, l := range a.Labels {
+			if !wanted[l] {
+				g.note(fmt.Sprintf("enum %s no longer has label %s, postgres cannot drop enum labels", c.Name, pq.QuoteLiteral(l)))
+			}
+		}
+	})
+}
+
+func (g *generator) createType(t postgres.Type) {
+	name := qualified(t.Schema, t.Name)
+	switch t.Kind {
+	case "enum":
+		var labels []string
+		for _, l := range t.Labels {
+			labels = append(labels, pq.QuoteLiteral(l))
+		}
+		g.add("CREATE TYPE %s AS ENUM (%s)", name, strings.Join(labels, ", "))
+	case "domain":
+		sql := fmt.Sprintf("CREATE DOMAIN %s AS %s", name, t.BaseType)
+		if t.Default != "" {
+			sql += " DEFAULT " + t.Default
+		}
+		if t.NotNull {
+			sql += " NOT NULL"
+		}
+		for _, con := range t.Constraints {
+			parts := strings.SplitN(con, ": ", 2)
+			if len(parts) == 2 && !strings.HasPrefix(parts[1], "NOT NULL") {
+				sql += fmt.Sprintf("\n    CONSTRAINT %s %s", pq.QuoteIdentifier(parts[0]), parts[1])
+			}
+		}
+		g.add("%s", sql)
+	case "composite":
+		var attrs []string
+		for _, a := range t.Attributes {
+			parts := strings.SplitN(a, " ", 2)
+			if len(parts) == 2 {
+				attrs = append(attrs, pq.QuoteIdentifier(parts[0])+" "+parts[1])
+			}
+		}
+		g.add("CREATE TYPE %s AS (\n    %s\n)", name, strings.Join(attrs, ",\n    "))
+	case "range":
+		g.add("CREATE TYPE %s AS RANGE (SUBTYPE = %s)", name, t.Subtype)
+	}
+}
+
+func (g *generator) dropTypes() {
+	from := types(g.from)
+	g.each(schemadiff.TypeObject, removed, func(c schemadiff.Change) {
+		t := from[c.Name]
+		kind := "TYPE"
+		if t.Kind == "domain" {
+			kind = "DOMAIN"
+		}
+		g.add("DROP %s %s", kind, qualified(t.Schema, t.Name))
+	})
+}
+
+var nextvalPattern = regexp.MustCompile(`nextval\('([^']+)'::regclass\)`)
+
+// createSequences creates the sequences a column default draws from, the
+// snapshot does not tie them to the migration otherwise.
+func (g *generator) createSequences(col postgres.TableColumn) {
+	for _, m := range nextvalPattern.FindAllStringSubmatch(col.Default, -1) {
+		schema, name := splitQualified(m[1])
+		if schema == "" {
+			schema = g.sequenceSchema(name, col.Schema)
+		}
+		if g.seenSequences[schema+"."+name] {
+			continue
+		}
+		g.seenSequences[schema+"."+name] = true
+		g.add("CREATE SEQUENCE IF NOT EXISTS %s", qualified(schema, name))
+	}
+}
+
+// sequenceSchema finds the schema of a sequence nextval names without one,
+// which it does when the schema is on the search path. The schema of the
+// column is preferred, as serial columns keep their sequence next to them.
+func (g *generator) sequenceSchema(name, schema string) string {
+	var found []string
+	for _, s := range g.to.Sequences {
+		if s.Name != name {
+			continue
+		}
+		if s.Schema == schema {
+			return schema
+		}
+		found = append(found, s.Schema)
+	}
+	if len(found) == 1 {
+		return found[0]
+	}
+	return schema
+}
+
+// ownSequences marks the sequences created for col as owned by it, like a
+// serial column's sequence, so they go away together with the column.
+func (g *generator) ownSequences(col postgres.TableColumn) {
+	for _, s := range g.to.Sequences {
+		if s.OwnerColumn != col.Name || !g.seenSequences[s.Schema+"."+s.Name] {
+			continue
+		}
+		if schema, table := splitQualified(s.OwnerTable); schema != col.Schema || table != col.Table {
+			continue
+		}
+		g.add("ALTER SEQUENCE %s OWNED BY %s.%s", qualified(s.Schema, s.Name), qualified(col.Schema, col.Table), pq.QuoteIdentifier(col.Name))
+	}
+}
+
+// splitQualified splits a name as postgres prints it, e.g. public."Users",
+// into its unquoted schema and name. The schema is empty for a name that is
+// not qualified.
+func splitQualified(s string) (schema, name string) {
+	var (
+		parts  []string
+		part   strings.Builder
+		quoted bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' && quoted && i+1 < len(s) && s[i+1] == '"':
+			part.WriteByte('"')
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == '.' && !quoted:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(c)
+		}
+	}
+	parts = append(parts, part.String())
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+func columnDefinition(c postgres.TableColumn) string {
+	def := pq.QuoteIdentifier(c.Name) + " " + c.Type
+	switch {
+	case c.Identity != "":
+		def += " GENERATED " + strings.ToUpper(c.Identity) + " AS IDENTITY"
+	case c.Generated && c.Default != "":
+		def += " GENERATED ALWAYS AS (" + c.Default + ") STORED"
+	case c.Default != "":
+		def += " DEFAULT " + c.Default
+	}
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+	return def
+}
+
+// generatedUnknown reports whether c is generated but the snapshot does not
+// say how, as is the case for snapshots taken before identities were
+// captured.
+func generatedUnknown(c postgres.TableColumn) bool {
+	return c.Generated && c.Identity == "" && c.Default == ""
+}
+
+func (g *generator) createTables() {
+	g.each(schemadiff.TableObject, added, func(c schemadiff.Change) {
+		var cols []postgres.TableColumn
+		for _, col := range g.to.Columns {
+			if col.Schema == c.Schema && col.Table == c.Table {
+				cols = append(cols, col)
+				g.createSequences(col)
+			}
+		}
+
+		var defs []string
+		for _, col := range cols {
+			if generatedUnknown(col) {
+				g.note(fmt.Sprintf("column %s.%s.%s is generated and needs a manual migration", col.Schema, col.Table, col.Name))
+			}
+			defs = append(defs, columnDefinition(col))
+		}
+		g.add("CREATE TABLE %s (\n    %s\n)", qualified(c.Schema, c.Table), strings.Join(defs, ",\n    "))
+		for _, col := range cols {
+			g.ownSequences(col)
+		}
+
+		for _, t := range g.to.Tables {
+			if t.Schema == c.Schema && t.Name == c.Table && t.Comment != "" {
+				g.add("COMMENT ON TABLE %s IS %s", qualified(t.Schema, t.Name), pq.QuoteLiteral(t.Comment))
+			}
+		}
+		for _, col := range cols {
+			if col.Comment != "" {
+				g.add("COMMENT ON COLUMN %s.%s IS %s", qualified(col.Schema, col.Table), pq.QuoteIdentifier(col.Name), pq.QuoteLiteral(col.Comment))
+			}
+		}
+	})
+}
+
+func (g *generator) alterTables() {
+	g.each(schemadiff.TableObject, changed, func(c schemadiff.Change) {
+		var a, b postgres.TableInfo
+		for _, t := range g.from.Tables {
+			if t.Schema == c.Schema && t.Name == c.Table {
+				a = t
+			}
+		}
+		for _, t := range g.to.Tables {
+			if t.Schema == c.Schema && t.Name == c.Table {
+				b = t
+			}
+		}
+		name := qualified(c.Schema, c.Table)
+		if a.Kind != b.Kind {
+			g.note(fmt.Sprintf("table %s changed from a %s to a %s and needs a manual migration", c.Name, a.Kind, b.Kind))
+		}
+		if a.Owner != b.Owner {
+			g.add("ALTER TABLE %s OWNER TO %s", name, pq.QuoteIdentifier(b.Owner))
+		}
+		if a.Comment != b.Comment {
+			comment := "NULL"
+			if b.Comment != "" {
+				comment = pq.QuoteLiteral(b.Comment)
+			}
+			g.add("COMMENT ON TABLE %s IS %s", name, comment)
+		}
+	})
+}
+
+func (g *generator) alterColumns() {
+	g.each(schemadiff.ColumnObject, added, func(c schemadiff.Change) {
+		col := g.toColumns[c.Name]
+		if generatedUnknown(col) {
+			g.note(fmt.Sprintf("column %s is generated and needs a manual migration", c.Name))
+		}
+		g.createSequences(col)
+		g.add("ALTER TABLE %s ADD COLUMN %s", qualified(c.Schema, c.Table), columnDefinition(col))
+		g.ownSequences(col)
+	})
+
+	g.each(schemadiff.ColumnObject, changed, func(c schemadiff.Change) {
+		a, b := g.fromColumns[c.Name], g.toColumns[c.Name]
+		table, col := qualified(c.Schema, c.Table), pq.QuoteIdentifier(b.Name)
+		computed := func(c postgres.TableColumn) bool { return c.Generated && c.Identity == "" }
+		if (computed(a) || computed(b)) && (a.Generated != b.Generated || a.Default != b.Default) {
+			g.note(fmt.Sprintf("generated column %s changed and needs a manual migration", c.Name))
+			a.Default, b.Default = "", ""
+		}
+		if a.Identity != b.Identity && a.Identity != "" && b.Identity == "" {
+			g.add("ALTER TABLE %s ALTER COLUMN %s DROP IDENTITY", table, col)
+		}
+		if a.Default != b.Default && a.Default != "" {
+			g.add("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, col)
+		}
+		if a.Type != b.Type {
+			g.add("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s", table, col, b.Type, col, b.Type)
+		}
+		if a.Default != b.Default && b.Default != "" {
+			g.createSequences(b)
+			g.add("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", table, col, b.Default)
+			g.ownSequences(b)
+		}
+		switch {
+		case a.Identity == b.Identity || b.Identity == "":
+		case a.Identity == "":
+			g.add("ALTER TABLE %s ALTER COLUMN %s ADD GENERATED %s AS IDENTITY", table, col, strings.ToUpper(b.Identity))
+		default:
+			g.add("ALTER TABLE %s ALTER COLUMN %s SET GENERATED %s", table, col, strings.ToUpper(b.Identity))
+		}
+		if a.NotNull != b.NotNull {
+			action := "DROP"
+			if b.NotNull {
+				action = "SET"
+			}
+			g.add("ALTER TABLE %s ALTER COLUMN %s %s NOT NULL", table, col, action)
+		}
+		if a.Comment != b.Comment {
+			comment := "NULL"
+			if b.Comment != "" {
+				comment = pq.QuoteLiteral(b.Comment)
+			}
+			g.add("COMMENT ON COLUMN %s.%s IS %s", table, col, comment)
+		}
+	})
+
+	g.each(schemadiff.ColumnObject, removed, func(c schemadiff.Change) {
+		col := g.fromColumns[c.Name]
+		g.add("ALTER TABLE %s DROP COLUMN %s", qualified(c.Schema, c.Table), pq.QuoteIdentifier(col.Name))
+	})
+}
+
+func (g *generator) dropTables() {
+	g.each(schemadiff.TableObject, removed, func(c schemadiff.Change) {
+		g.add("DROP TABLE %s", qualified(c.Schema, c.Table))
+	})
+}
+
+// Verify applies the statements to the client's database inside a sandbox
+// that is rolled back afterwards, and reports the first statement that fails.
+// The sandbox is limited like the playground, so a statement waiting for a
+// lock fails rather than stalling the queries queued up behind it.
+func Verify(ctx context.Context, c *postgres.Client, stmts []Statement) error {
+	return c.Sandbox(ctx, func(tx *sqlx.Tx) error {
+		if err := postgres.LimitSandbox(ctx, tx); err != nil {
+			return err
+		}
+		for i, s := range stmts {
+			if s.SQL == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, s.SQL); err != nil {
+				return fmt.Errorf("statement %d failed: %v\n%s", i+1, err, s.SQL)
+			}
+		}
+		return nil
+	})
+}
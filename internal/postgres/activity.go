@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Backend is a server process from pg_stat_activity. QuerySeconds is how long
+// an active backend has been running its query, and how long any other has
+// been in its state. BlockedBy holds the pids of the backends holding the
+// locks it waits for.
+type Backend struct {
+	PID           int64         `db:"pid"`
+	Username      string        `db:"username"`
+	Database      string        `db:"database"`
+	Application   string        `db:"application"`
+	ClientAddr    string        `db:"client_addr"`
+	BackendType   string        `db:"backend_type"`
+	State         string        `db:"state"`
+	WaitEventType string        `db:"wait_event_type"`
+	WaitEvent     string        `db:"wait_event"`
+	QuerySeconds  float64       `db:"query_seconds"`
+	XactSeconds   float64       `db:"xact_seconds"`
+	BlockedBy     pq.Int64Array `db:"blocked_by"`
+	Query         string        `db:"query"`
+}
+
+// Activity returns every backend of the server other than the one running
+// the query, grouped by state with the active ones first and idle ones last,
+// and the longest running first within a state.
+func (c *Client) Activity(ctx context.Context) ([]Backend, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT pid, COALESCE(usename, '') AS username, COALESCE(datname, '') AS database,
+				COALESCE(application_name, '') AS application, COALESCE(host(client_addr), '') AS client_addr,
+				COALESCE(backend_type, '') AS backend_type, COALESCE(state, '') AS state,
+				COALESCE(wait_event_type, '') AS wait_event_type, COALESCE(wait_event, '') AS wait_event,
+				COALESCE(EXTRACT(EPOCH FROM now() - CASE state
+					WHEN 'active' THEN query_start
+					ELSE state_change END), 0)::float8 AS query_seconds,
+				COALESCE(EXTRACT(EPOCH FROM now() - xact_start), 0)::float8 AS xact_seconds,
+				pg_blocking_pids(pid) AS blocked_by,
+				COALESCE(query, '') AS query
+		FROM pg_stat_activity
+		WHERE pid <> pg_backend_pid()
+		ORDER BY CASE state
+					WHEN 'active' THEN 0
+					WHEN 'idle in transaction' THEN 1
+					WHEN 'idle in transaction (aborted)' THEN 2
+					WHEN 'idle' THEN 4
+					ELSE 3 END,
+				query_seconds DESC`
+
+	var backends []Backend
+	return backends, c.db.SelectContext(ctx, &backends, query)
+}
+
+// CancelBackend cancels the query the backend is running. It reports false
+// when the backend no longer exists.
+func (c *Client) CancelBackend(ctx context.Context, pid int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var ok bool
+	return ok, c.db.GetContext(ctx, &ok, `SELECT pg_cancel_backend($1)`, pid)
+}
+
+// TerminateBackend ends the backend's session. It reports false when the
+// backend no longer exists.
+func (c *Client) TerminateBackend(ctx context.Context, pid int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var ok bool
+	return ok, c.db.GetContext(ctx, &ok, `SELECT pg_terminate_backend($1)`, pid)
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type (
+	// PublicGrant is a privilege granted to PUBLIC, and so to every role, on
+	// a schema or table.
+	PublicGrant struct {
+		Kind      string `db:"kind"`
+		Schema    string `db:"schema"`
+		Name      string `db:"name"`
+		Privilege string `db:"privilege"`
+	}
+
+	// SecurityDefinerFunction is a function that runs with the privileges of
+	// its owner. Without a fixed search_path a caller can make it resolve
+	// names to objects the caller created.
+	SecurityDefinerFunction struct {
+		Schema     string `db:"schema"`
+		Name       string `db:"name"`
+		Owner      string `db:"owner"`
+		SearchPath string `db:"search_path"`
+	}
+
+	// Connection is a client connection and whether it uses SSL.
+	Connection struct {
+		PID        int64  `db:"pid"`
+		Username   string `db:"usename"`
+		Database   string `db:"datname"`
+		ClientAddr string `db:"client_addr"`
+		SSL        bool   `db:"ssl"`
+	}
+
+	// HBARule is an entry of pg_hba.conf.
+	HBARule struct {
+		Line       int            `db:"line_number"`
+		Type       string         `db:"type"`
+		Databases  pq.StringArray `db:"database"`
+		Users      pq.StringArray `db:"user_name"`
+		Address    string         `db:"address"`
+		AuthMethod string         `db:"auth_method"`
+		Error      string         `db:"error"`
+	}
+)
+
+// PublicGrants returns the CREATE privileges PUBLIC holds on user schemas
+// and the SELECT privileges it holds on user tables and views.
+func (c *Client) PublicGrants(ctx context.Context) ([]PublicGrant, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT 'schema' AS kind, n.nspname AS schema, n.nspname AS name, a.privilege_type AS privilege
+		FROM pg_namespace n
+		CROSS JOIN LATERAL aclexplode(COALESCE(n.nspacl, acldefault('n', n.nspowner))) a
+		WHERE a.grantee = 0 AND a.privilege_type = 'CREATE' AND ` + userSchemas + `
+		UNION ALL
+		SELECT 'table', n.nspname, c.relname, a.privilege_type
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL aclexplode(COALESCE(c.relacl, acldefault('r', c.relowner))) a
+		WHERE a.grantee = 0 AND a.privilege_type = 'SELECT'
+			AND c.relkind IN ('r', 'p', 'v', 'm', 'f') AND ` + userSchemas + `
+		ORDER BY 1, 2, 3`
+
+	var grants []PublicGrant
+	return grants, c.db.SelectContext(ctx, &grants, query)
+}
+
+// SecurityDefinerFunctions returns the SECURITY DEFINER functions in the
+// user schemas with the search_path they set, empty when they set none.
+func (c *Client) SecurityDefinerFunctions(ctx context.Context) ([]SecurityDefinerFunction, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT n.nspname AS schema,
+				p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')' AS name,
+				pg_get_userbyid(p.proowner) AS owner,
+				COALESCE((SELECT substr(cfg, length('search_path=') + 1)
+					FROM unnest(p.proconfig) AS cfg WHERE cfg LIKE 'search_path=%'), '') AS search_path
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE p.prosecdef AND ` + userSchemas + `
+		ORDER BY schema, name`
+
+	var fns []SecurityDefinerFunction
+	return fns, c.db.SelectContext(ctx, &fns, query)
+}
+
+// Connections returns the client connections made over TCP, leaving out
+// those over unix sockets. Without superuser or pg_read_all_stats the client
+// address of other roles' connections is hidden, that is an error rather
+// than a shorter list.
+func (c *Client) Connections(ctx context.Context) ([]Connection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// client_port is -1 for unix sockets and only NULL when hidden.
+	var hidden int
+	err := c.db.GetContext(ctx, &hidden, `
+		SELECT count(*)
+		FROM pg_stat_activity
+		WHERE backend_type = 'client backend' AND client_port IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	if hidden > 0 {
+		return nil, fmt.Errorf("the addresses of %d connection(s) are hidden, pg_read_all_stats is needed to see them", hidden)
+	}
+
+	query := `
+		SELECT a.pid, COALESCE(a.usename, '') AS usename, COALESCE(a.datname, '') AS datname,
+				host(a.client_addr) AS client_addr, COALESCE(s.ssl, false) AS ssl
+		FROM pg_stat_activity a
+		LEFT JOIN pg_stat_ssl s ON s.pid = a.pid
+		WHERE a.client_addr IS NOT NULL
+		ORDER BY a.pid`
+
+	var conns []Connection
+	return conns, c.db.SelectContext(ctx, &conns, query)
+}
+
+// HBARules returns the entries of pg_hba.conf. Only superusers can read them
+// unless access to pg_hba_file_rules was granted.
+func (c *Client) HBARules(ctx context.Context) ([]HBARule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT line_number, COALESCE(type, '') AS type,
+				COALESCE(database, '{}') AS database, COALESCE(user_name, '{}') AS user_name,
+				COALESCE(address, '') AS address, COALESCE(auth_method, '') AS auth_method,
+				COALESCE(error, '') AS error
+		FROM pg_catalog.pg_hba_file_rules
+		ORDER BY line_number`
+
+	var rules []HBARule
+	return rules, c.db.SelectContext(ctx, &rules, query)
+}
@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Bloat is the estimated space wasted by a table, or by a B-tree index when
+// Index is set. The estimate comes from the statistics in pg_stats, so it is
+// only as fresh as the last analyze. Unreliable is set when the statistics
+// cannot describe the relation, e.g. columns without stats or of type name.
+type Bloat struct {
+	Schema         string  `db:"table_schema"`
+	Table          string  `db:"table_name"`
+	Index          string  `db:"index_name"`
+	RealSize       int64   `db:"real_size"`
+	WastedSize     int64   `db:"wasted_size"`
+	WastedPercent  float64 `db:"wasted_percent"`
+	FillFactor     int     `db:"fillfactor"`
+	Unreliable     bool    `db:"unreliable"`
+	LastVacuum     string  `db:"last_vacuum"`
+	LastAutovacuum string  `db:"last_autovacuum"`
+	Exact          bool    `db:"-"`
+}
+
+// Bloat estimates the wasted space of every table and B-tree index in the
+// user schemas, largest waste first. The estimates follow the well known
+// table and B-tree bloat queries by ioguix. Relations that were never
+// analyzed count as empty.
+func (c *Client) Bloat(ctx context.Context) ([]Bloat, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var tables []Bloat
+	if err := c.db.SelectContext(ctx, &tables, tableBloatQuery); err != nil {
+		return nil, err
+	}
+	var indexes []Bloat
+	if err := c.db.SelectContext(ctx, &indexes, indexBloatQuery); err != nil {
+		return nil, err
+	}
+
+	all := append(tables, indexes...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].WastedSize > all[j].WastedSize })
+	return all, nil
+}
+
+// HasExtension reports whether the named extension is installed in the
+// database.
+func (c *Client) HasExtension(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var exists bool
+	return exists, c.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = $1)`, name)
+}
+
+// MeasureBloat replaces the estimate of b with an exact measurement taken
+// with the pgstattuple extension. This reads the whole relation, so it is
+// given far longer than the catalog queries.
+func (c *Client) MeasureBloat(ctx context.Context, b Bloat) (Bloat, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	if b.Index == "" {
+		var m struct {
+			Size   int64 `db:"table_len"`
+			Wasted int64 `db:"wasted"`
+		}
+		query := `SELECT table_len, dead_tuple_len + free_space AS wasted FROM pgstattuple($1::regclass)`
+		if err := c.db.GetContext(ctx, &m, query, quoteQualified(b.Schema, b.Table)); err != nil {
+			return b, err
+		}
+		b.RealSize, b.WastedSize = m.Size, m.Wasted
+	} else {
+		var m struct {
+			Size    int64           `db:"index_size"`
+			Density sql.NullFloat64 `db:"avg_leaf_density"`
+		}
+		query := `SELECT index_size, avg_leaf_density FROM pgstatindex($1::regclass)`
+		if err := c.db.GetContext(ctx, &m, query, quoteQualified(b.Schema, b.Index)); err != nil {
+			return b, err
+		}
+		// leaf pages are only filled up to the fillfactor, the space above it
+		// is not waste.
+		b.RealSize, b.WastedSize = m.Size, 0
+		if m.Density.Valid && !math.IsNaN(m.Density.Float64) && b.FillFactor > 0 {
+			unused := float64(b.FillFactor) - m.Density.Float64
+			if unused > 0 {
+				b.WastedSize = int64(float64(m.Size) * unused / 100)
+			}
+		}
+	}
+
+	b.WastedPercent = 0
+	if b.RealSize > 0 {
+		b.WastedPercent = 100 * float64(b.WastedSize) / float64(b.RealSize)
+	}
+	b.Exact, b.Unreliable = true, false
+	return b, nil
+}
+
+func quoteQualified(schema, name string) string {
+	return pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(name)
+}
+
+const tableBloatQuery = `
+		SELECT schemaname AS table_schema, tblname AS table_name, '' AS index_name,
+				(bs * tblpages)::bigint AS real_size,
+				CASE WHEN tblpages - est_tblpages_ff > 0 THEN ((tblpages - est_tblpages_ff) * bs)::bigint ELSE 0 END AS wasted_size,
+				CASE WHEN tblpages > 0 AND tblpages - est_tblpages_ff > 0
+					THEN 100 * (tblpages - est_tblpages_ff) / tblpages::float8
+					ELSE 0 END AS wasted_percent,
+				fillfactor, is_na AS unreliable,
+				COALESCE(to_char(st.last_vacuum, 'YYYY-MM-DD HH24:MI:SS'), 'never') AS last_vacuum,
+				COALESCE(to_char(st.last_autovacuum, 'YYYY-MM-DD HH24:MI:SS'), 'never') AS last_autovacuum
+		FROM (
+			SELECT ceil(reltuples / ((bs - page_hdr) * fillfactor / (tpl_size * 100))) + ceil(toasttuples / 4) AS est_tblpages_ff,
+					tblpages, fillfactor, bs, tblid, schemaname, tblname, is_na
+			FROM (
+				SELECT (4 + tpl_hdr_size + tpl_data_size + (2 * ma)
+						- CASE WHEN tpl_hdr_size % ma = 0 THEN ma ELSE tpl_hdr_size % ma END
+						- CASE WHEN ceil(tpl_data_size)::int % ma = 0 THEN ma ELSE ceil(tpl_data_size)::int % ma END
+						) AS tpl_size,
+						(heappages + toastpages) AS tblpages, reltuples, toasttuples, bs, page_hdr,
+						tblid, schemaname, tblname, fillfactor, is_na
+				FROM (
+					SELECT tbl.oid AS tblid, n.nspname AS schemaname, tbl.relname AS tblname, greatest(tbl.reltuples, 0) AS reltuples,
+							tbl.relpages AS heappages, COALESCE(toast.relpages, 0) AS toastpages,
+							COALESCE(toast.reltuples, 0) AS toasttuples,
+							COALESCE(substring(array_to_string(tbl.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::smallint, 100) AS fillfactor,
+							current_setting('block_size')::numeric AS bs,
+							CASE WHEN version() ~ 'mingw32' OR version() ~ '64-bit|x86_64|ppc64|ia64|amd64' THEN 8 ELSE 4 END AS ma,
+							24 AS page_hdr,
+							23 + CASE WHEN max(COALESCE(s.null_frac, 0)) > 0 THEN (7 + count(s.attname)) / 8 ELSE 0::int END AS tpl_hdr_size,
+							sum((1 - COALESCE(s.null_frac, 0)) * COALESCE(s.avg_width, 0)) AS tpl_data_size,
+							bool_or(att.atttypid = 'pg_catalog.name'::regtype)
+								OR sum(CASE WHEN att.attnum > 0 THEN 1 ELSE 0 END) <> count(s.attname) AS is_na
+					FROM pg_attribute att
+					JOIN pg_class tbl ON tbl.oid = att.attrelid
+					JOIN pg_namespace n ON n.oid = tbl.relnamespace
+					LEFT JOIN pg_stats s ON s.schemaname = n.nspname AND s.tablename = tbl.relname
+						AND NOT s.inherited AND s.attname = att.attname
+					LEFT JOIN pg_class toast ON toast.oid = tbl.reltoastrelid
+					WHERE NOT att.attisdropped AND tbl.relkind IN ('r', 'm') AND ` + userSchemas + `
+					GROUP BY 1, 2, 3, 4, 5, 6, 7, 8, 9, 10
+				) AS s
+			) AS s2
+			WHERE tpl_size > 0
+		) AS s3
+		LEFT JOIN pg_stat_user_tables st ON st.relid = tblid`
+
+const indexBloatQuery = `
+		SELECT nspname AS table_schema, tblname AS table_name, idxname AS index_name,
+				(bs * relpages)::bigint AS real_size,
+				CASE WHEN relpages > est_pages_ff THEN (bs * (relpages - est_pages_ff))::bigint ELSE 0 END AS wasted_size,
+				CASE WHEN relpages > est_pages_ff THEN 100 * (relpages - est_pages_ff)::float8 / relpages ELSE 0 END AS wasted_percent,
+				fillfactor, is_na AS unreliable,
+				COALESCE(to_char(st.last_vacuum, 'YYYY-MM-DD HH24:MI:SS'), 'never') AS last_vacuum,
+				COALESCE(to_char(st.last_autovacuum, 'YYYY-MM-DD HH24:MI:SS'), 'never') AS last_autovacuum
+		FROM (
+			SELECT COALESCE(1 + ceil(reltuples / floor((bs - pageopqdata - pagehdr) * fillfactor / (100 * (4 + nulldatahdrwidth)::float8))), 0) AS est_pages_ff,
+					bs, nspname, tblname, idxname, relpages, fillfactor, is_na, tbloid
+			FROM (
+				SELECT bs, nspname, tblname, idxname, reltuples, relpages, fillfactor, tbloid,
+						(index_tuple_hdr_bm + maxalign
+							- CASE WHEN index_tuple_hdr_bm % maxalign = 0 THEN maxalign ELSE index_tuple_hdr_bm % maxalign END
+							+ nulldatawidth + maxalign
+							- CASE
+								WHEN nulldatawidth = 0 THEN 0
+								WHEN nulldatawidth::integer % maxalign = 0 THEN maxalign
+								ELSE nulldatawidth::integer % maxalign END
+						)::numeric AS nulldatahdrwidth,
+						pagehdr, pageopqdata, is_na
+				FROM (
+					SELECT n.nspname, i.tblname, i.idxname, i.reltuples, i.relpages, i.tbloid, i.fillfactor,
+							current_setting('block_size')::numeric AS bs,
+							CASE WHEN version() ~ 'mingw32' OR version() ~ '64-bit|x86_64|ppc64|ia64|amd64' THEN 8 ELSE 4 END AS maxalign,
+							24 AS pagehdr,
+							16 AS pageopqdata,
+							CASE WHEN max(COALESCE(s.null_frac, 0)) = 0 THEN 8 ELSE 8 + ((32 + 8 - 1) / 8) END AS index_tuple_hdr_bm,
+							sum((1 - COALESCE(s.null_frac, 0)) * COALESCE(s.avg_width, 1024)) AS nulldatawidth,
+							max(CASE WHEN i.atttypid = 'pg_catalog.name'::regtype THEN 1 ELSE 0 END) > 0 AS is_na
+					FROM (
+						SELECT ct.relname AS tblname, ct.relnamespace, ic.idxname, ic.reltuples, ic.relpages,
+								ic.tbloid, ic.fillfactor,
+								COALESCE(a1.attname, a2.attname) AS attname,
+								COALESCE(a1.atttypid, a2.atttypid) AS atttypid,
+								CASE WHEN a1.attnum IS NULL THEN ic.idxname ELSE ct.relname END AS attrelname
+						FROM (
+							SELECT idxname, reltuples, relpages, tbloid, idxoid, fillfactor, indkey,
+									generate_series(1, indnatts) AS attpos
+							FROM (
+								SELECT ci.relname AS idxname, greatest(ci.reltuples, 0) AS reltuples, ci.relpages, i.indrelid AS tbloid,
+										i.indexrelid AS idxoid,
+										COALESCE(substring(array_to_string(ci.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::smallint, 90) AS fillfactor,
+										i.indnatts,
+										string_to_array(textin(int2vectorout(i.indkey)), ' ')::int[] AS indkey
+								FROM pg_index i
+								JOIN pg_class ci ON ci.oid = i.indexrelid
+								WHERE ci.relam = (SELECT oid FROM pg_am WHERE amname = 'btree') AND ci.relpages > 0
+							) AS idx_data
+						) AS ic
+						JOIN pg_class ct ON ct.oid = ic.tbloid
+						LEFT JOIN pg_attribute a1 ON ic.indkey[ic.attpos] <> 0
+							AND a1.attrelid = ic.tbloid AND a1.attnum = ic.indkey[ic.attpos]
+						LEFT JOIN pg_attribute a2 ON ic.indkey[ic.attpos] = 0
+							AND a2.attrelid = ic.idxoid AND a2.attnum = ic.attpos
+					) i
+					JOIN pg_namespace n ON n.oid = i.relnamespace
+					JOIN pg_stats s ON s.schemaname = n.nspname AND s.tablename = i.attrelname AND s.attname = i.attname
+					WHERE ` + userSchemas + `
+					GROUP BY 1, 2, 3, 4, 5, 6, 7
+				) AS rows_data_stats
+			) AS rows_hdr_pdg_stats
+		) AS relation_stats
+		LEFT JOIN pg_stat_user_tables st ON st.relid = tbloid`
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Catalog is the read only view of a database's structure that the explorer
+// is built on. *Client implements it against a live database and
+// *SnapshotCatalog against a previously captured Snapshot.
+type Catalog interface {
+	Schemas(ctx context.Context) ([]Schema, error)
+	SchemasUserCreated(ctx context.Context) ([]Schema, error)
+	Tables(ctx context.Context) ([]PGTable, error)
+	TablesBySize(ctx context.Context) ([]PGTable, error)
+	DescribeTable(ctx context.Context, table string) ([]Column, error)
+	Columns(ctx context.Context, schema string) ([]TableColumn, error)
+	ForeignKeys(ctx context.Context, schema, table string) ([]ForeignKey, error)
+	ReferencingKeys(ctx context.Context, schema, table string) ([]ForeignKey, error)
+	SchemaForeignKeys(ctx context.Context, schema string) ([]ForeignKey, error)
+	Views(ctx context.Context) ([]View, error)
+	MaterializedViews(ctx context.Context) ([]View, error)
+	Functions(ctx context.Context) ([]Function, error)
+	FunctionsUserCreated(ctx context.Context) ([]Function, error)
+	Sequences(ctx context.Context) ([]Sequence, error)
+	Types(ctx context.Context) ([]Type, error)
+}
+
+var (
+	_ Catalog = (*Client)(nil)
+	_ Catalog = (*SnapshotCatalog)(nil)
+)
+
+// SnapshotCatalog answers catalog questions from a Snapshot, no database
+// connection required.
+type SnapshotCatalog struct {
+	snap *Snapshot
+}
+
+func NewSnapshotCatalog(snap *Snapshot) *SnapshotCatalog {
+	return &SnapshotCatalog{snap: snap}
+}
+
+func (s *SnapshotCatalog) Schemas(context.Context) ([]Schema, error) {
+	return s.snap.Schemas, nil
+}
+
+func (s *SnapshotCatalog) SchemasUserCreated(context.Context) ([]Schema, error) {
+	var schemas []Schema
+	for _, sch := range s.snap.Schemas {
+		if !IsSystemSchema(sch.Name) && sch.Name != "public" {
+			schemas = append(schemas, sch)
+		}
+	}
+	return schemas, nil
+}
+
+func (s *SnapshotCatalog) Tables(context.Context) ([]PGTable, error) {
+	var tables []PGTable
+	for _, t := range s.snap.Tables {
+		tables = append(tables, PGTable{
+			Catalog:     s.snap.Database,
+			Name:        t.Name,
+			Owner:       "BASE TABLE",
+			Schema:      t.Schema,
+			TableSize:   int(t.TableSize),
+			IndexesSize: int(t.IndexesSize),
+			TotalSize:   int(t.TotalSize),
+		})
+	}
+	return tables, nil
+}
+
+func (s *SnapshotCatalog) TablesBySize(ctx context.Context) ([]PGTable, error) {
+	tables, _ := s.Tables(ctx)
+	sort.SliceStable(tables, func(i, j int) bool { return tables[i].TableSize > tables[j].TableSize })
+	return tables, nil
+}
+
+func (s *SnapshotCatalog) DescribeTable(_ context.Context, table string) ([]Column, error) {
+	var cols []Column
+	for _, c := range s.snap.Columns {
+		if c.Table != table {
+			continue
+		}
+		nullable := "YES"
+		if c.NotNull {
+			nullable = "NO"
+		}
+		cols = append(cols, Column{
+			Catalog:  s.snap.Database,
+			Schema:   c.Schema,
+			Name:     c.Table,
+			Column:   c.Name,
+			Nullable: nullable,
+			Type:     c.Type,
+		})
+	}
+	return cols, nil
+}
+
+func (s *SnapshotCatalog) Columns(_ context.Context, schema string) ([]TableColumn, error) {
+	var cols []TableColumn
+	for _, c := range s.snap.Columns {
+		if c.Schema == schema {
+			cols = append(cols, c)
+		}
+	}
+	return cols, nil
+}
+
+func (s *SnapshotCatalog) ForeignKeys(_ context.Context, schema, table string) ([]ForeignKey, error) {
+	return s.foreignKeys(func(fk ForeignKey) bool { return fk.Schema == schema && fk.Table == table }), nil
+}
+
+func (s *SnapshotCatalog) ReferencingKeys(_ context.Context, schema, table string) ([]ForeignKey, error) {
+	return s.foreignKeys(func(fk ForeignKey) bool { return fk.RefSchema == schema && fk.RefTable == table }), nil
+}
+
+func (s *SnapshotCatalog) SchemaForeignKeys(_ context.Context, schema string) ([]ForeignKey, error) {
+	return s.foreignKeys(func(fk ForeignKey) bool { return fk.Schema == schema }), nil
+}
+
+func (s *SnapshotCatalog) foreignKeys(keep func(ForeignKey) bool) []ForeignKey {
+	var keys []ForeignKey
+	for _, fk := range s.snap.ForeignKeys {
+		if keep(fk) {
+			keys = append(keys, fk)
+		}
+	}
+	return keys
+}
+
+func (s *SnapshotCatalog) Views(context.Context) ([]View, error) {
+	return s.snap.Views, nil
+}
+
+func (s *SnapshotCatalog) MaterializedViews(context.Context) ([]View, error) {
+	return s.snap.MaterializedViews, nil
+}
+
+// Functions only holds the user created functions, system functions are not
+// part of a snapshot.
+func (s *SnapshotCatalog) Functions(context.Context) ([]Function, error) {
+	return s.snap.Functions, nil
+}
+
+func (s *SnapshotCatalog) FunctionsUserCreated(context.Context) ([]Function, error) {
+	return s.snap.Functions, nil
+}
+
+func (s *SnapshotCatalog) Sequences(context.Context) ([]Sequence, error) {
+	return s.snap.Sequences, nil
+}
+
+func (s *SnapshotCatalog) Types(context.Context) ([]Type, error) {
+	return s.snap.Types, nil
+}
+
+// IsSystemSchema reports whether a schema is part of postgres itself.
+func IsSystemSchema(name string) bool {
+	return name == "information_schema" || name == "pg_catalog" ||
+		strings.HasPrefix(name, "pg_toast") || strings.HasPrefix(name, "pg_temp_")
+}
@@ -139,7 +139,10 @@ func (c *Client) DescribeTable(ctx context.Context, table string) ([]Column, err
 	defer cancel()
 
 	query := `
-		SELECT table_catalog, table_schema, table_name, column_name, is_nullable, data_type
+		SELECT table_catalog, table_schema, table_name, column_name, is_nullable,
+				CASE data_type
+					WHEN 'USER-DEFINED' THEN udt_schema || '.' || udt_name
+					ELSE data_type END AS data_type
 		FROM INFORMATION_SCHEMA.COLUMNS
 		WHERE table_name = $1`
 
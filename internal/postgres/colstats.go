@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type (
+	// ColumnStats are the planner statistics ANALYZE gathered for a column.
+	// NDistinct is a count when positive and, when negative, the negated
+	// fraction of rows that are distinct. Correlation is nil when unknown.
+	ColumnStats struct {
+		RowEstimate     int64           `db:"row_estimate"`
+		NullFrac        float64         `db:"null_frac"`
+		NDistinct       float64         `db:"n_distinct"`
+		AvgWidth        int             `db:"avg_width"`
+		Correlation     *float64        `db:"correlation"`
+		MostCommonVals  pq.StringArray  `db:"most_common_vals"`
+		MostCommonFreqs pq.Float64Array `db:"most_common_freqs"`
+		HistogramBounds pq.StringArray  `db:"histogram_bounds"`
+	}
+
+	// ColumnSample are exact figures for a column computed over a sample of
+	// the table's pages.
+	ColumnSample struct {
+		Percent  float64
+		Rows     int64        `db:"rows"`
+		NonNull  int64        `db:"non_null"`
+		Distinct int64        `db:"n_distinct"`
+		AvgWidth float64      `db:"avg_width"`
+		Top      []ValueCount `db:"-"`
+	}
+
+	// ValueCount is how often a value occurs.
+	ValueCount struct {
+		Value string `db:"value"`
+		Count int64  `db:"count"`
+	}
+)
+
+// Distinct estimates the number of distinct values in the column.
+func (s ColumnStats) Distinct() float64 {
+	if s.NDistinct < 0 {
+		return -s.NDistinct * float64(s.RowEstimate)
+	}
+	return s.NDistinct
+}
+
+// ColumnStatistics returns the planner statistics of a column, or nil when
+// the table was never analyzed. For tables with inheritance children or
+// partitions the statistics over the whole tree are preferred.
+func (c *Client) ColumnStatistics(ctx context.Context, schema, table, column string) (*ColumnStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT GREATEST(c.reltuples, 0)::bigint AS row_estimate,
+				s.null_frac::float8 AS null_frac, s.n_distinct::float8 AS n_distinct, s.avg_width,
+				s.correlation::float8 AS correlation,
+				COALESCE(s.most_common_vals::text::text[], '{}') AS most_common_vals,
+				COALESCE(s.most_common_freqs::float8[], '{}') AS most_common_freqs,
+				COALESCE(s.histogram_bounds::text::text[], '{}') AS histogram_bounds
+		FROM pg_catalog.pg_stats s
+		JOIN pg_namespace n ON n.nspname = s.schemaname
+		JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = s.tablename
+		WHERE s.schemaname = $1 AND s.tablename = $2 AND s.attname = $3
+		ORDER BY s.inherited DESC
+		LIMIT 1`
+
+	var stats ColumnStats
+	err := c.db.GetContext(ctx, &stats, query, schema, table, column)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// SampleColumn computes exact figures for a column over roughly percent of
+// the table's pages, along with its top most frequent values. The queries
+// run in a read-only transaction.
+func (c *Client) SampleColumn(ctx context.Context, schema, table, column string, percent float64, top int) (ColumnSample, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	tx, err := c.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return ColumnSample{}, err
+	}
+	defer tx.Rollback()
+
+	// the same seed gives both queries the same sample
+	from := fmt.Sprintf("%s TABLESAMPLE SYSTEM (%s) REPEATABLE (0)",
+		quoteQualified(schema, table), strconv.FormatFloat(percent, 'f', -1, 64))
+	col := pq.QuoteIdentifier(column)
+
+	sample := ColumnSample{Percent: percent}
+	query := `
+		SELECT count(*) AS rows, count(` + col + `) AS non_null, count(DISTINCT ` + col + `::text) AS n_distinct,
+				COALESCE(avg(pg_column_size(` + col + `)), 0)::float8 AS avg_width
+		FROM ` + from
+	if err := tx.GetContext(ctx, &sample, query); err != nil {
+		return ColumnSample{}, err
+	}
+
+	query = `
+		SELECT ` + col + `::text AS value, count(*) AS count
+		FROM ` + from + `
+		WHERE ` + col + ` IS NOT NULL
+		GROUP BY 1
+		ORDER BY 2 DESC, 1
+		LIMIT $1`
+	if err := tx.SelectContext(ctx, &sample.Top, query, top); err != nil {
+		return ColumnSample{}, err
+	}
+	return sample, nil
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// TableColumn is a column of a table with the key information needed to
+// describe the table's structure. Type is the fully formatted type, e.g.
+// character varying(255) rather than information_schema's data_type.
+// Generated is set for identity and generated columns, whose values the
+// database fills in itself.
+type TableColumn struct {
+	Schema     string `db:"table_schema"`
+	Table      string `db:"table_name"`
+	Name       string `db:"column_name"`
+	Position   int    `db:"ordinal_position"`
+	Type       string `db:"data_type"`
+	NotNull    bool   `db:"not_null"`
+	Default    string `db:"column_default"`
+	PrimaryKey bool   `db:"primary_key"`
+	Unique     bool   `db:"is_unique"`
+	Comment    string `db:"comment"`
+	Generated  bool   `db:"generated"`
+	// Identity is "always" or "by default" for identity columns. A generated
+	// column that is not an identity is computed from Default.
+	Identity string `db:"identity"`
+}
+
+const tableColumnsQuery = `
+		SELECT n.nspname AS table_schema, c.relname AS table_name, a.attname AS column_name,
+				a.attnum AS ordinal_position, format_type(a.atttypid, a.atttypmod) AS data_type,
+				a.attnotnull AS not_null, COALESCE(pg_get_expr(ad.adbin, ad.adrelid), '') AS column_default,
+				EXISTS (
+					SELECT 1 FROM pg_constraint pk
+					WHERE pk.conrelid = c.oid AND pk.contype = 'p' AND a.attnum = ANY(pk.conkey)
+				) AS primary_key,
+				EXISTS (
+					SELECT 1 FROM pg_index i
+					WHERE i.indrelid = c.oid AND i.indisunique AND i.indnatts = 1 AND i.indkey[0] = a.attnum
+				) AS is_unique,
+				COALESCE(col_description(c.oid, a.attnum), '') AS comment,
+				(a.attidentity <> '' OR a.attgenerated <> '') AS generated,
+				CASE a.attidentity WHEN 'a' THEN 'always' WHEN 'd' THEN 'by default' ELSE '' END AS identity
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+		WHERE c.relkind IN ('r', 'p', 'f') AND NOT c.relispartition
+			AND a.attnum > 0 AND NOT a.attisdropped`
+
+// Columns returns the columns of every table in a schema, ordered by table
+// and position.
+func (c *Client) Columns(ctx context.Context, schema string) ([]TableColumn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := tableColumnsQuery + `
+			AND n.nspname = $1
+		ORDER BY table_name, ordinal_position`
+
+	var cols []TableColumn
+	return cols, c.db.SelectContext(ctx, &cols, query, schema)
+}
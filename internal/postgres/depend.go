@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// Object identifies a catalog object the way pg_depend does, by the catalog it
+// lives in, its oid and, for columns, its attribute number. DepType and Depth
+// describe how the object was reached when it is the result of a dependency
+// lookup.
+type Object struct {
+	ClassID  int64  `db:"classid"`
+	ObjID    int64  `db:"objid"`
+	SubID    int    `db:"objsubid"`
+	Type     string `db:"object_type"`
+	Schema   string `db:"schema_name"`
+	Name     string `db:"object_name"`
+	Identity string `db:"identity"`
+	DepType  string `db:"deptype"`
+	Depth    int    `db:"depth"`
+}
+
+// pgClassOID is the oid of the pg_class catalog itself.
+const pgClassOID = 1259
+
+// IsRelation reports whether the object is a table, view, sequence or any
+// other pg_class entry as a whole, as opposed to one of its columns.
+func (o Object) IsRelation() bool {
+	return o.ClassID == pgClassOID && o.SubID == 0
+}
+
+// normalizedDependsQuery rewrites pg_depend so that dependencies recorded
+// against a view's rewrite rule or a column default show up as the view or
+// the column itself. Only normal and automatic dependencies are kept, internal
+// ones are implementation details of the object they belong to.
+const normalizedDependsQuery = `
+		normalized AS (
+			SELECT
+				CASE
+					WHEN d.classid = 'pg_rewrite'::regclass THEN 'pg_class'::regclass::oid
+					WHEN d.classid = 'pg_attrdef'::regclass THEN 'pg_class'::regclass::oid
+					ELSE d.classid END AS classid,
+				CASE
+					WHEN d.classid = 'pg_rewrite'::regclass THEN rw.ev_class
+					WHEN d.classid = 'pg_attrdef'::regclass THEN ad.adrelid
+					ELSE d.objid END AS objid,
+				CASE
+					WHEN d.classid = 'pg_rewrite'::regclass THEN 0
+					WHEN d.classid = 'pg_attrdef'::regclass THEN ad.adnum::int
+					ELSE d.objsubid END AS objsubid,
+				d.refclassid, d.refobjid, d.refobjsubid, d.deptype
+			FROM pg_depend d
+			LEFT JOIN pg_rewrite rw ON d.classid = 'pg_rewrite'::regclass AND rw.oid = d.objid
+			LEFT JOIN pg_attrdef ad ON d.classid = 'pg_attrdef'::regclass AND ad.oid = d.objid
+			WHERE d.deptype IN ('n', 'a')
+		)`
+
+const identifyObjectColumns = `
+		o.type AS object_type, COALESCE(o.schema, '') AS schema_name,
+		COALESCE(o.name, '') AS object_name, COALESCE(o.identity, '') AS identity`
+
+// Objects returns the relations, functions and types outside of the system
+// schemas.
+func (c *Client) Objects(ctx context.Context) ([]Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		WITH objs AS (
+			SELECT 'pg_class'::regclass::oid AS classid, c.oid AS objid, c.relnamespace AS nsp
+			FROM pg_class c
+			WHERE c.relkind IN ('r', 'p', 'v', 'm', 'S', 'f')
+			UNION ALL
+			SELECT 'pg_proc'::regclass::oid, p.oid, p.pronamespace
+			FROM pg_proc p
+			UNION ALL
+			SELECT 'pg_type'::regclass::oid, t.oid, t.typnamespace
+			FROM pg_type t
+			LEFT JOIN pg_class rel ON rel.oid = t.typrelid
+			WHERE t.typtype IN ('e', 'd', 'r') OR (t.typtype = 'c' AND rel.relkind = 'c')
+		)
+		SELECT objs.classid::bigint AS classid, objs.objid::bigint AS objid, 0 AS objsubid,
+				'' AS deptype, 0 AS depth,` + identifyObjectColumns + `
+		FROM objs
+		JOIN pg_namespace n ON n.oid = objs.nsp
+		CROSS JOIN LATERAL pg_identify_object(objs.classid, objs.objid, 0) o
+		WHERE n.nspname NOT IN ('information_schema', 'pg_catalog')
+			AND n.nspname NOT LIKE 'pg_toast%'
+			AND n.nspname NOT LIKE 'pg_temp_%'
+		ORDER BY object_type, identity`
+
+	var objs []Object
+	return objs, c.db.SelectContext(ctx, &objs, query)
+}
+
+// ObjectColumns returns the columns of a relation.
+func (c *Client) ObjectColumns(ctx context.Context, rel Object) ([]Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT 'pg_class'::regclass::oid::bigint AS classid, a.attrelid::bigint AS objid, a.attnum::int AS objsubid,
+				'' AS deptype, 0 AS depth,` + identifyObjectColumns + `
+		FROM pg_attribute a
+		CROSS JOIN LATERAL pg_identify_object('pg_class'::regclass, a.attrelid, a.attnum) o
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`
+
+	var cols []Object
+	return cols, c.db.SelectContext(ctx, &cols, query, rel.ObjID)
+}
+
+// Dependents returns the objects that directly depend on obj. For a relation
+// this includes objects depending on any of its columns.
+func (c *Client) Dependents(ctx context.Context, obj Object) ([]Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		WITH` + normalizedDependsQuery + `,
+		deps AS (
+			SELECT DISTINCT n.classid, n.objid, n.objsubid, n.deptype
+			FROM normalized n
+			WHERE n.refclassid = $1 AND n.refobjid = $2 AND ($3 = 0 OR n.refobjsubid = $3)
+				AND NOT (n.classid = $1 AND n.objid = $2)
+		)
+		SELECT deps.classid::bigint AS classid, deps.objid::bigint AS objid, deps.objsubid,
+				deps.deptype::text AS deptype, 1 AS depth,` + identifyObjectColumns + `
+		FROM deps
+		CROSS JOIN LATERAL pg_identify_object(deps.classid, deps.objid, deps.objsubid) o
+		ORDER BY object_type, identity`
+
+	var objs []Object
+	return objs, c.db.SelectContext(ctx, &objs, query, obj.ClassID, obj.ObjID, obj.SubID)
+}
+
+// Dependencies returns the objects obj directly depends on. Dependencies of a
+// view's rewrite rule are reported as dependencies of the view.
+func (c *Client) Dependencies(ctx context.Context, obj Object) ([]Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		WITH` + normalizedDependsQuery + `,
+		deps AS (
+			SELECT DISTINCT n.refclassid AS classid, n.refobjid AS objid, n.refobjsubid AS objsubid, n.deptype
+			FROM normalized n
+			WHERE n.classid = $1 AND n.objid = $2 AND ($3 = 0 OR n.objsubid = $3)
+				AND NOT (n.refclassid = $1 AND n.refobjid = $2)
+		)
+		SELECT deps.classid::bigint AS classid, deps.objid::bigint AS objid, deps.objsubid,
+				deps.deptype::text AS deptype, 1 AS depth,` + identifyObjectColumns + `
+		FROM deps
+		CROSS JOIN LATERAL pg_identify_object(deps.classid, deps.objid, deps.objsubid) o
+		ORDER BY object_type, identity`
+
+	var objs []Object
+	return objs, c.db.SelectContext(ctx, &objs, query, obj.ClassID, obj.ObjID, obj.SubID)
+}
+
+// Impact returns everything that transitively depends on obj, each object
+// once at the shallowest depth it was found, ordered as a depth first walk so
+// the result can be rendered as a tree.
+func (c *Client) Impact(ctx context.Context, obj Object) ([]Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		WITH RECURSIVE` + normalizedDependsQuery + `,
+		impact AS (
+			SELECT n.classid, n.objid, n.objsubid, n.deptype, 1 AS depth,
+					ARRAY[n.objid] AS path, ARRAY[n.objid::text || '.' || n.objsubid::text] AS sort_path
+			FROM normalized n
+			WHERE n.refclassid = $1 AND n.refobjid = $2 AND ($3 = 0 OR n.refobjsubid = $3)
+				AND NOT (n.classid = $1 AND n.objid = $2)
+			UNION ALL
+			SELECT n.classid, n.objid, n.objsubid, n.deptype, i.depth + 1,
+					i.path || n.objid, i.sort_path || (n.objid::text || '.' || n.objsubid::text)
+			FROM impact i
+			JOIN normalized n ON n.refclassid = i.classid AND n.refobjid = i.objid
+				AND (i.objsubid = 0 OR n.refobjsubid = i.objsubid)
+			WHERE NOT n.objid = ANY(i.path) AND n.objid <> $2 AND i.depth < 20
+		),
+		shallowest AS (
+			SELECT DISTINCT ON (classid, objid, objsubid) classid, objid, objsubid, deptype, depth, sort_path
+			FROM impact
+			ORDER BY classid, objid, objsubid, depth
+		)
+		SELECT s.classid::bigint AS classid, s.objid::bigint AS objid, s.objsubid,
+				s.deptype::text AS deptype, s.depth,` + identifyObjectColumns + `
+		FROM shallowest s
+		CROSS JOIN LATERAL pg_identify_object(s.classid, s.objid, s.objsubid) o
+		ORDER BY s.sort_path`
+
+	var objs []Object
+	return objs, c.db.SelectContext(ctx, &objs, query, obj.ClassID, obj.ObjID, obj.SubID)
+}
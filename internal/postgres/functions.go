@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// Function is a routine from pg_proc; functions, procedures, aggregates and
+// window functions alike.
+type Function struct {
+	Schema          string `db:"schema_name"`
+	Name            string `db:"function_name"`
+	Arguments       string `db:"arguments"`
+	Result          string `db:"result"`
+	Kind            string `db:"kind"`
+	Language        string `db:"language"`
+	Volatility      string `db:"volatility"`
+	SecurityDefiner bool   `db:"security_definer"`
+	Owner           string `db:"owner"`
+	Source          string `db:"source"`
+}
+
+// Signature is the schema qualified name with the argument list.
+func (f Function) Signature() string {
+	return f.Schema + "." + f.Name + "(" + f.Arguments + ")"
+}
+
+const functionsQuery = `
+		SELECT n.nspname AS schema_name, p.proname AS function_name,
+				pg_get_function_arguments(p.oid) AS arguments,
+				COALESCE(pg_get_function_result(p.oid), '') AS result,
+				CASE p.prokind
+					WHEN 'p' THEN 'procedure'
+					WHEN 'a' THEN 'aggregate'
+					WHEN 'w' THEN 'window'
+					ELSE 'function' END AS kind,
+				l.lanname AS language,
+				CASE p.provolatile
+					WHEN 'i' THEN 'immutable'
+					WHEN 's' THEN 'stable'
+					ELSE 'volatile' END AS volatility,
+				p.prosecdef AS security_definer,
+				pg_get_userbyid(p.proowner) AS owner,
+				CASE WHEN p.prokind = 'a' THEN
+					'CREATE AGGREGATE ' || quote_ident(n.nspname) || '.' || quote_ident(p.proname) ||
+					'(' || pg_get_function_arguments(p.oid) || ') (' ||
+					E'\n    SFUNC = ' || a.aggtransfn::text ||
+					E',\n    STYPE = ' || format_type(a.aggtranstype, NULL) ||
+					CASE WHEN a.aggfinalfn::oid <> 0 THEN E',\n    FINALFUNC = ' || a.aggfinalfn::text ELSE '' END ||
+					CASE WHEN a.agginitval IS NOT NULL THEN E',\n    INITCOND = ' || quote_literal(a.agginitval) ELSE '' END ||
+					E'\n);'
+				ELSE pg_get_functiondef(p.oid) END AS source
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		JOIN pg_language l ON l.oid = p.prolang
+		LEFT JOIN pg_aggregate a ON a.aggfnoid = p.oid`
+
+func (c *Client) Functions(ctx context.Context) ([]Function, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := functionsQuery + `
+		ORDER BY schema_name, function_name, arguments`
+
+	var fns []Function
+	return fns, c.db.SelectContext(ctx, &fns, query)
+}
+
+func (c *Client) FunctionsUserCreated(ctx context.Context) ([]Function, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := functionsQuery + `
+		WHERE n.nspname NOT IN ('information_schema', 'pg_catalog')
+			AND n.nspname NOT LIKE 'pg_toast%'
+			AND n.nspname NOT LIKE 'pg_temp_%'
+		ORDER BY schema_name, function_name, arguments`
+
+	var fns []Function
+	return fns, c.db.SelectContext(ctx, &fns, query)
+}
@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Kinds of index issues reported by IndexHealth.
+const (
+	IndexUnused       = "unused"
+	IndexDuplicate    = "duplicate"
+	IndexOverlapping  = "overlapping"
+	IndexInvalid      = "invalid"
+	IndexMissingForFK = "missing fk index"
+)
+
+type (
+	// IndexReport is the outcome of the index health checks. StatsReset is
+	// when the scan counters were last reset, unused indexes are unused
+	// since then.
+	IndexReport struct {
+		StatsReset string
+		Issues     []IndexIssue
+	}
+
+	// IndexIssue is a problem with an index, or a foreign key missing one.
+	// Index is the constraint name for missing foreign key indexes, and Size
+	// the size of the referencing table. Suggestion is a statement that
+	// addresses the issue.
+	IndexIssue struct {
+		Kind       string
+		Schema     string
+		Table      string
+		Index      string
+		Size       int64
+		Scans      int64
+		Reason     string
+		Definition string
+		Suggestion string
+	}
+
+	indexUsage struct {
+		Schema      string `db:"table_schema"`
+		Table       string `db:"table_name"`
+		Name        string `db:"index_name"`
+		Size        int64  `db:"index_size"`
+		Scans       int64  `db:"index_scans"`
+		Unique      bool   `db:"is_unique"`
+		Primary     bool   `db:"is_primary"`
+		Valid       bool   `db:"is_valid"`
+		Constraint  bool   `db:"backs_constraint"`
+		Method      string `db:"method"`
+		Keys        string `db:"key_columns"`
+		OpClasses   string `db:"opclasses"`
+		Expressions string `db:"expressions"`
+		Predicate   string `db:"predicate"`
+		Definition  string `db:"definition"`
+	}
+
+	unindexedKey struct {
+		Schema    string         `db:"table_schema"`
+		Table     string         `db:"table_name"`
+		Name      string         `db:"constraint_name"`
+		Columns   pq.StringArray `db:"columns"`
+		RefTable  string         `db:"ref_table"`
+		TableSize int64          `db:"table_size"`
+	}
+)
+
+// IndexHealth checks the indexes of the user schemas for indexes that are
+// never scanned, exact duplicates, indexes that are a prefix of another
+// index, invalid indexes and foreign keys without an index to support them.
+// Issues are ordered by size, largest first.
+func (c *Client) IndexHealth(ctx context.Context) (IndexReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var report IndexReport
+	const statsResetQuery = `
+		SELECT COALESCE(to_char(stats_reset, 'YYYY-MM-DD HH24:MI:SS TZ'), 'never')
+		FROM pg_stat_database
+		WHERE datname = current_database()`
+	if err := c.db.GetContext(ctx, &report.StatsReset, statsResetQuery); err != nil {
+		return report, err
+	}
+
+	query := `
+		SELECT n.nspname AS table_schema, t.relname AS table_name, ic.relname AS index_name,
+				pg_relation_size(i.indexrelid) AS index_size, COALESCE(s.idx_scan, 0) AS index_scans,
+				i.indisunique AS is_unique, i.indisprimary AS is_primary, i.indisvalid AS is_valid,
+				EXISTS (SELECT 1 FROM pg_constraint co WHERE co.conindid = i.indexrelid) AS backs_constraint,
+				am.amname AS method, i.indkey::text AS key_columns, i.indclass::text AS opclasses,
+				COALESCE(pg_get_expr(i.indexprs, i.indrelid), '') AS expressions,
+				COALESCE(pg_get_expr(i.indpred, i.indrelid), '') AS predicate,
+				pg_get_indexdef(i.indexrelid) AS definition
+		FROM pg_index i
+		JOIN pg_class ic ON ic.oid = i.indexrelid
+		JOIN pg_class t ON t.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_am am ON am.oid = ic.relam
+		LEFT JOIN pg_stat_user_indexes s ON s.indexrelid = i.indexrelid
+		WHERE ` + userSchemas + `
+		ORDER BY table_schema, table_name, index_name`
+
+	var indexes []indexUsage
+	if err := c.db.SelectContext(ctx, &indexes, query); err != nil {
+		return report, err
+	}
+
+	// a foreign key is supported by an index whose leading columns are the
+	// key's columns, in any order.
+	fkQuery := `
+		SELECT n.nspname AS table_schema, t.relname AS table_name, co.conname AS constraint_name,
+				ARRAY(
+					SELECT a.attname
+					FROM unnest(co.conkey) WITH ORDINALITY AS k(attnum, ord)
+					JOIN pg_attribute a ON a.attrelid = co.conrelid AND a.attnum = k.attnum
+					ORDER BY k.ord
+				) AS columns,
+				co.confrelid::regclass::text AS ref_table,
+				pg_relation_size(t.oid) AS table_size
+		FROM pg_constraint co
+		JOIN pg_class t ON t.oid = co.conrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE co.contype = 'f' AND ` + userSchemas + `
+			AND NOT EXISTS (
+				SELECT 1
+				FROM pg_index i
+				WHERE i.indrelid = co.conrelid AND i.indpred IS NULL
+					AND (i.indkey::int2[])[0:cardinality(co.conkey) - 1] @> co.conkey
+			)
+		ORDER BY table_schema, table_name, constraint_name`
+
+	var keys []unindexedKey
+	if err := c.db.SelectContext(ctx, &keys, fkQuery); err != nil {
+		return report, err
+	}
+
+	report.Issues = indexIssues(indexes, keys)
+	return report, nil
+}
+
+func indexIssues(indexes []indexUsage, keys []unindexedKey) []IndexIssue {
+	var issues []IndexIssue
+	add := func(kind string, idx indexUsage, reason, suggestion string) {
+		issues = append(issues, IndexIssue{
+			Kind:       kind,
+			Schema:     idx.Schema,
+			Table:      idx.Table,
+			Index:      idx.Name,
+			Size:       idx.Size,
+			Scans:      idx.Scans,
+			Reason:     reason,
+			Definition: idx.Definition,
+			Suggestion: suggestion,
+		})
+	}
+	dropIndex := func(idx indexUsage) string {
+		return "DROP INDEX " + pq.QuoteIdentifier(idx.Schema) + "." + pq.QuoteIdentifier(idx.Name)
+	}
+	// constraint backed indexes cannot be dropped on their own
+	droppable := func(idx indexUsage) bool { return !idx.Constraint }
+
+	byTable := make(map[string][]indexUsage)
+	var tables []string
+	for _, idx := range indexes {
+		key := idx.Schema + "." + idx.Table
+		if _, ok := byTable[key]; !ok {
+			tables = append(tables, key)
+		}
+		byTable[key] = append(byTable[key], idx)
+
+		if !idx.Valid {
+			add(IndexInvalid, idx, "the index is invalid, most likely a failed CREATE INDEX CONCURRENTLY, and is not used by queries but still maintained on writes",
+				"REINDEX INDEX "+pq.QuoteIdentifier(idx.Schema)+"."+pq.QuoteIdentifier(idx.Name))
+			continue
+		}
+		if idx.Scans == 0 && !idx.Unique && droppable(idx) {
+			add(IndexUnused, idx, "never scanned since the statistics were reset", dropIndex(idx))
+		}
+	}
+
+	for _, table := range tables {
+		idxs := byTable[table]
+		duplicated := make(map[string]bool)
+		for i, a := range idxs {
+			for _, b := range idxs[i+1:] {
+				if !a.Valid || !b.Valid || a.Method != b.Method || a.Predicate != b.Predicate || a.Expressions != b.Expressions {
+					continue
+				}
+				if a.Keys != b.Keys || a.OpClasses != b.OpClasses {
+					continue
+				}
+				keep, drop := a, b
+				if keepRank(b) > keepRank(a) {
+					keep, drop = b, a
+				}
+				if droppable(drop) && !duplicated[drop.Name] {
+					duplicated[drop.Name] = true
+					add(IndexDuplicate, drop, "same columns as "+keep.Name, dropIndex(drop))
+				}
+			}
+		}
+
+		for _, a := range idxs {
+			if duplicated[a.Name] || !a.Valid || a.Unique || !droppable(a) || a.Method != "btree" || a.Expressions != "" {
+				continue
+			}
+			for _, b := range idxs {
+				if a.Name == b.Name || !b.Valid || b.Method != "btree" || b.Predicate != a.Predicate || b.Expressions != "" {
+					continue
+				}
+				if isPrefix(a.Keys, b.Keys) && isPrefix(a.OpClasses, b.OpClasses) {
+					add(IndexOverlapping, a, "its columns are a prefix of "+b.Name+", which can serve the same queries", dropIndex(a))
+					break
+				}
+			}
+		}
+	}
+
+	for _, k := range keys {
+		cols := make([]string, len(k.Columns))
+		for i, col := range k.Columns {
+			cols[i] = pq.QuoteIdentifier(col)
+		}
+		issues = append(issues, IndexIssue{
+			Kind:   IndexMissingForFK,
+			Schema: k.Schema,
+			Table:  k.Table,
+			Index:  k.Name,
+			Size:   k.TableSize,
+			Reason: fmt.Sprintf("foreign key (%s) to %s has no index, deletes and updates on %s scan %s",
+				strings.Join(k.Columns, ", "), k.RefTable, k.RefTable, k.Table),
+			Suggestion: fmt.Sprintf("CREATE INDEX ON %s.%s (%s)", pq.QuoteIdentifier(k.Schema), pq.QuoteIdentifier(k.Table), strings.Join(cols, ", ")),
+		})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Size > issues[j].Size })
+	return issues
+}
+
+// keepRank orders duplicate indexes by how much reason there is to keep them:
+// indexes enforcing a constraint first, then unique ones.
+func keepRank(idx indexUsage) int {
+	switch {
+	case idx.Constraint:
+		return 2
+	case idx.Unique:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// isPrefix reports whether the space separated list a is a strict prefix of b.
+func isPrefix(a, b string) bool {
+	af, bf := strings.Fields(a), strings.Fields(b)
+	if len(af) >= len(bf) {
+		return false
+	}
+	for i := range af {
+		if af[i] != bf[i] {
+			return false
+		}
+	}
+	return true
+}
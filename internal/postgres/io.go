@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// TableIO is the buffer cache and scan activity of a table since the
+	// statistics were last reset. Hit percentages are 100 for tables that
+	// were never read.
+	TableIO struct {
+		Schema         string  `db:"table_schema"`
+		Table          string  `db:"table_name"`
+		Size           int64   `db:"size"`
+		LiveTuples     int64   `db:"live_tuples"`
+		HeapHit        int64   `db:"heap_blks_hit"`
+		HeapRead       int64   `db:"heap_blks_read"`
+		HeapHitPercent float64 `db:"heap_hit_percent"`
+		IdxHit         int64   `db:"idx_blks_hit"`
+		IdxRead        int64   `db:"idx_blks_read"`
+		IdxHitPercent  float64 `db:"idx_hit_percent"`
+		SeqScans       int64   `db:"seq_scan"`
+		SeqTupRead     int64   `db:"seq_tup_read"`
+		IdxScans       int64   `db:"idx_scan"`
+		IdxScanPercent float64 `db:"idx_scan_percent"`
+		Updates        int64   `db:"n_tup_upd"`
+		HotUpdates     int64   `db:"n_tup_hot_upd"`
+		HotPercent     float64 `db:"hot_percent"`
+	}
+
+	// IndexIO is the buffer cache and scan activity of an index since the
+	// statistics were last reset.
+	IndexIO struct {
+		Schema     string  `db:"table_schema"`
+		Table      string  `db:"table_name"`
+		Index      string  `db:"index_name"`
+		Size       int64   `db:"size"`
+		Hit        int64   `db:"idx_blks_hit"`
+		Read       int64   `db:"idx_blks_read"`
+		HitPercent float64 `db:"hit_percent"`
+		Scans      int64   `db:"idx_scan"`
+	}
+)
+
+// TableIO returns the cache hit ratios and scan counts of every user table,
+// lowest heap hit ratio first.
+func (c *Client) TableIO(ctx context.Context) ([]TableIO, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT io.schemaname AS table_schema, io.relname AS table_name,
+				pg_relation_size(io.relid) AS size,
+				COALESCE(s.n_live_tup, 0) AS live_tuples,
+				COALESCE(io.heap_blks_hit, 0) AS heap_blks_hit, COALESCE(io.heap_blks_read, 0) AS heap_blks_read,
+				COALESCE(100.0 * io.heap_blks_hit / NULLIF(io.heap_blks_hit + io.heap_blks_read, 0), 100)::float8 AS heap_hit_percent,
+				COALESCE(io.idx_blks_hit, 0) AS idx_blks_hit, COALESCE(io.idx_blks_read, 0) AS idx_blks_read,
+				COALESCE(100.0 * io.idx_blks_hit / NULLIF(io.idx_blks_hit + io.idx_blks_read, 0), 100)::float8 AS idx_hit_percent,
+				COALESCE(s.seq_scan, 0) AS seq_scan, COALESCE(s.seq_tup_read, 0) AS seq_tup_read,
+				COALESCE(s.idx_scan, 0) AS idx_scan,
+				COALESCE(100.0 * s.idx_scan / NULLIF(s.seq_scan + s.idx_scan, 0), 0)::float8 AS idx_scan_percent,
+				COALESCE(s.n_tup_upd, 0) AS n_tup_upd, COALESCE(s.n_tup_hot_upd, 0) AS n_tup_hot_upd,
+				COALESCE(100.0 * s.n_tup_hot_upd / NULLIF(s.n_tup_upd, 0), 0)::float8 AS hot_percent
+		FROM pg_catalog.pg_statio_user_tables io
+		LEFT JOIN pg_catalog.pg_stat_user_tables s ON s.relid = io.relid
+		ORDER BY heap_hit_percent, heap_blks_read DESC, table_schema, table_name`
+
+	var tables []TableIO
+	return tables, c.db.SelectContext(ctx, &tables, query)
+}
+
+// IndexIO returns the cache hit ratio of every user index, lowest first.
+func (c *Client) IndexIO(ctx context.Context) ([]IndexIO, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT io.schemaname AS table_schema, io.relname AS table_name, io.indexrelname AS index_name,
+				pg_relation_size(io.indexrelid) AS size,
+				COALESCE(io.idx_blks_hit, 0) AS idx_blks_hit, COALESCE(io.idx_blks_read, 0) AS idx_blks_read,
+				COALESCE(100.0 * io.idx_blks_hit / NULLIF(io.idx_blks_hit + io.idx_blks_read, 0), 100)::float8 AS hit_percent,
+				COALESCE(s.idx_scan, 0) AS idx_scan
+		FROM pg_catalog.pg_statio_user_indexes io
+		LEFT JOIN pg_catalog.pg_stat_user_indexes s ON s.indexrelid = io.indexrelid
+		ORDER BY hit_percent, idx_blks_read DESC, table_schema, table_name, index_name`
+
+	var indexes []IndexIO
+	return indexes, c.db.SelectContext(ctx, &indexes, query)
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ForeignKey is a foreign key constraint pointing from Columns of Schema.Table
+// to RefColumns of RefSchema.RefTable. Columns and RefColumns are ordered by
+// their position within the constraint.
+type ForeignKey struct {
+	Name       string         `db:"constraint_name"`
+	Schema     string         `db:"table_schema"`
+	Table      string         `db:"table_name"`
+	Columns    pq.StringArray `db:"columns"`
+	RefSchema  string         `db:"ref_table_schema"`
+	RefTable   string         `db:"ref_table_name"`
+	RefColumns pq.StringArray `db:"ref_columns"`
+}
+
+const foreignKeysQuery = `
+		SELECT c.conname AS constraint_name,
+				tn.nspname AS table_schema, t.relname AS table_name,
+				ARRAY(
+					SELECT a.attname
+					FROM unnest(c.conkey) WITH ORDINALITY k(attnum, n)
+					JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+					ORDER BY k.n
+				)::text[] AS columns,
+				rn.nspname AS ref_table_schema, r.relname AS ref_table_name,
+				ARRAY(
+					SELECT a.attname
+					FROM unnest(c.confkey) WITH ORDINALITY k(attnum, n)
+					JOIN pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.attnum
+					ORDER BY k.n
+				)::text[] AS ref_columns
+		FROM pg_constraint c
+		JOIN pg_class t ON t.oid = c.conrelid
+		JOIN pg_namespace tn ON tn.oid = t.relnamespace
+		JOIN pg_class r ON r.oid = c.confrelid
+		JOIN pg_namespace rn ON rn.oid = r.relnamespace
+		WHERE c.contype = 'f'`
+
+// ForeignKeys returns the outgoing foreign keys of the given table.
+func (c *Client) ForeignKeys(ctx context.Context, schema, table string) ([]ForeignKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := foreignKeysQuery + `
+			AND tn.nspname = $1 AND t.relname = $2
+		ORDER BY constraint_name`
+
+	var keys []ForeignKey
+	return keys, c.db.SelectContext(ctx, &keys, query, schema, table)
+}
+
+// ReferencingKeys returns the foreign keys of other tables that reference the
+// given table.
+func (c *Client) ReferencingKeys(ctx context.Context, schema, table string) ([]ForeignKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := foreignKeysQuery + `
+			AND rn.nspname = $1 AND r.relname = $2
+		ORDER BY table_schema, table_name, constraint_name`
+
+	var keys []ForeignKey
+	return keys, c.db.SelectContext(ctx, &keys, query, schema, table)
+}
+
+// SchemaForeignKeys returns the foreign keys of all tables in a schema.
+func (c *Client) SchemaForeignKeys(ctx context.Context, schema string) ([]ForeignKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := foreignKeysQuery + `
+			AND tn.nspname = $1
+		ORDER BY table_name, constraint_name`
+
+	var keys []ForeignKey
+	return keys, c.db.SelectContext(ctx, &keys, query, schema)
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// WraparoundLimit is the transaction age at which postgres stops accepting
+// writes to protect itself from transaction ID wraparound.
+const WraparoundLimit = 2147483647
+
+type (
+	// TableMaintenance is the vacuum and analyze state of a table. The
+	// thresholds are those autovacuum acts on, taking the table's storage
+	// parameters over the server settings. XIDAge is the age of the oldest
+	// unfrozen transaction ID in the table or its TOAST table.
+	TableMaintenance struct {
+		Schema             string `db:"table_schema"`
+		Table              string `db:"table_name"`
+		LiveTuples         int64  `db:"live_tuples"`
+		DeadTuples         int64  `db:"dead_tuples"`
+		ModSinceAnalyze    int64  `db:"mod_since_analyze"`
+		LastVacuum         string `db:"last_vacuum"`
+		LastAutovacuum     string `db:"last_autovacuum"`
+		LastAnalyze        string `db:"last_analyze"`
+		LastAutoanalyze    string `db:"last_autoanalyze"`
+		AutovacuumEnabled  bool   `db:"autovacuum_enabled"`
+		VacuumThreshold    int64  `db:"vacuum_threshold"`
+		AnalyzeThreshold   int64  `db:"analyze_threshold"`
+		XIDAge             int64  `db:"xid_age"`
+		FreezeMaxAge       int64  `db:"freeze_max_age"`
+		ReloptionOverrides string `db:"reloptions"`
+	}
+
+	// DatabaseAge is the age of a database's oldest unfrozen transaction ID.
+	DatabaseAge struct {
+		Name         string `db:"datname"`
+		XIDAge       int64  `db:"xid_age"`
+		FreezeMaxAge int64  `db:"freeze_max_age"`
+	}
+)
+
+// NeedsVacuum reports whether autovacuum considers the table due for a
+// vacuum.
+func (t TableMaintenance) NeedsVacuum() bool {
+	return t.DeadTuples > t.VacuumThreshold
+}
+
+// NeedsAnalyze reports whether autovacuum considers the table due for an
+// analyze.
+func (t TableMaintenance) NeedsAnalyze() bool {
+	return t.ModSinceAnalyze > t.AnalyzeThreshold
+}
+
+// WraparoundPercent is how far the table is on its way to wraparound.
+func (t TableMaintenance) WraparoundPercent() float64 {
+	return 100 * float64(t.XIDAge) / WraparoundLimit
+}
+
+// WraparoundPercent is how far the database is on its way to wraparound.
+func (d DatabaseAge) WraparoundPercent() float64 {
+	return 100 * float64(d.XIDAge) / WraparoundLimit
+}
+
+// Maintenance returns the vacuum, analyze and freeze state of every table in
+// the user schemas, oldest transaction age first.
+func (c *Client) Maintenance(ctx context.Context) ([]TableMaintenance, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT n.nspname AS table_schema, c.relname AS table_name,
+				COALESCE(s.n_live_tup, 0) AS live_tuples, COALESCE(s.n_dead_tup, 0) AS dead_tuples,
+				COALESCE(s.n_mod_since_analyze, 0) AS mod_since_analyze,
+				COALESCE(to_char(s.last_vacuum, 'YYYY-MM-DD HH24:MI:SS'), 'never') AS last_vacuum,
+				COALESCE(to_char(s.last_autovacuum, 'YYYY-MM-DD HH24:MI:SS'), 'never') AS last_autovacuum,
+				COALESCE(to_char(s.last_analyze, 'YYYY-MM-DD HH24:MI:SS'), 'never') AS last_analyze,
+				COALESCE(to_char(s.last_autoanalyze, 'YYYY-MM-DD HH24:MI:SS'), 'never') AS last_autoanalyze,
+				current_setting('autovacuum')::bool AND COALESCE(o.enabled, true) AS autovacuum_enabled,
+				(COALESCE(o.vacuum_threshold, current_setting('autovacuum_vacuum_threshold')::float8)
+					+ COALESCE(o.vacuum_scale_factor, current_setting('autovacuum_vacuum_scale_factor')::float8)
+					* greatest(c.reltuples, 0))::bigint AS vacuum_threshold,
+				(COALESCE(o.analyze_threshold, current_setting('autovacuum_analyze_threshold')::float8)
+					+ COALESCE(o.analyze_scale_factor, current_setting('autovacuum_analyze_scale_factor')::float8)
+					* greatest(c.reltuples, 0))::bigint AS analyze_threshold,
+				greatest(age(c.relfrozenxid), COALESCE(age(t.relfrozenxid), 0)) AS xid_age,
+				COALESCE(o.freeze_max_age, current_setting('autovacuum_freeze_max_age')::bigint) AS freeze_max_age,
+				COALESCE(array_to_string(c.reloptions, ', '), '') AS reloptions
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_class t ON t.oid = c.reltoastrelid
+		LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+		CROSS JOIN LATERAL (
+			SELECT max(CASE WHEN option_name = 'autovacuum_enabled' THEN option_value END)::bool AS enabled,
+					max(CASE WHEN option_name = 'autovacuum_vacuum_threshold' THEN option_value END)::float8 AS vacuum_threshold,
+					max(CASE WHEN option_name = 'autovacuum_vacuum_scale_factor' THEN option_value END)::float8 AS vacuum_scale_factor,
+					max(CASE WHEN option_name = 'autovacuum_analyze_threshold' THEN option_value END)::float8 AS analyze_threshold,
+					max(CASE WHEN option_name = 'autovacuum_analyze_scale_factor' THEN option_value END)::float8 AS analyze_scale_factor,
+					max(CASE WHEN option_name = 'autovacuum_freeze_max_age' THEN option_value END)::bigint AS freeze_max_age
+			FROM pg_options_to_table(c.reloptions)
+		) o
+		WHERE c.relkind IN ('r', 'm') AND ` + userSchemas + `
+		ORDER BY xid_age DESC, table_schema, table_name`
+
+	var tables []TableMaintenance
+	return tables, c.db.SelectContext(ctx, &tables, query)
+}
+
+// DatabaseAges returns the transaction age of every database that accepts
+// connections, oldest first.
+func (c *Client) DatabaseAges(ctx context.Context) ([]DatabaseAge, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT datname, age(datfrozenxid) AS xid_age,
+				current_setting('autovacuum_freeze_max_age')::bigint AS freeze_max_age
+		FROM pg_database
+		WHERE datallowconn
+		ORDER BY xid_age DESC`
+
+	var dbs []DatabaseAge
+	return dbs, c.db.SelectContext(ctx, &dbs, query)
+}
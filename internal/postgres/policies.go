@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type (
+	// RLSTable is a table with row-level security enabled or with policies
+	// defined on it. Policies only apply to its owner when Forced is set.
+	RLSTable struct {
+		Schema   string `db:"table_schema"`
+		Table    string `db:"table_name"`
+		Owner    string `db:"owner"`
+		Enabled  bool   `db:"enabled"`
+		Forced   bool   `db:"forced"`
+		Policies int    `db:"policies"`
+	}
+
+	// Policy is a row-level security policy. Using filters the rows a
+	// command sees, WithCheck the rows it may write.
+	Policy struct {
+		Schema     string         `db:"schemaname"`
+		Table      string         `db:"tablename"`
+		Name       string         `db:"policyname"`
+		Permissive string         `db:"permissive"`
+		Roles      pq.StringArray `db:"roles"`
+		Command    string         `db:"cmd"`
+		Using      string         `db:"qual"`
+		WithCheck  string         `db:"with_check"`
+	}
+)
+
+// RLSTables returns the tables in the user schemas that have row-level
+// security enabled or policies defined.
+func (c *Client) RLSTables(ctx context.Context) ([]RLSTable, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT n.nspname AS table_schema, c.relname AS table_name, pg_get_userbyid(c.relowner) AS owner,
+				c.relrowsecurity AS enabled, c.relforcerowsecurity AS forced,
+				(SELECT count(*) FROM pg_policy p WHERE p.polrelid = c.oid) AS policies
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND ` + userSchemas + `
+			AND (c.relrowsecurity OR EXISTS (SELECT 1 FROM pg_policy p WHERE p.polrelid = c.oid))
+		ORDER BY table_schema, table_name`
+
+	var tables []RLSTable
+	return tables, c.db.SelectContext(ctx, &tables, query)
+}
+
+// Policies returns the row-level security policies defined on a table.
+func (c *Client) Policies(ctx context.Context, schema, table string) ([]Policy, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT schemaname, tablename, policyname, permissive, roles, cmd,
+				COALESCE(qual, '') AS qual, COALESCE(with_check, '') AS with_check
+		FROM pg_catalog.pg_policies
+		WHERE schemaname = $1 AND tablename = $2
+		ORDER BY policyname`
+
+	var policies []Policy
+	return policies, c.db.SelectContext(ctx, &policies, query, schema, table)
+}
+
+// SandboxExecAs runs a statement in a sandbox transaction as another role,
+// so it is subject to that role's privileges and row-level security
+// policies. The connected role has to be a member of role. Changes made by
+// the statement are undone afterwards, so statements run as different roles
+// all start from the same data. Statements are checked and sent the same way
+// as by SandboxExec.
+func SandboxExecAs(ctx context.Context, tx *sqlx.Tx, role, query string) (Result, error) {
+	if err := checkSandboxStatement(query); err != nil {
+		return Result{}, err
+	}
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT pgkons_role"); err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrSandboxAborted, err)
+	}
+	res, err := sandboxExecAs(ctx, tx, role, query)
+	if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT pgkons_role"); rbErr != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrSandboxAborted, rbErr)
+	}
+	if _, relErr := tx.ExecContext(ctx, "RELEASE SAVEPOINT pgkons_role"); relErr != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrSandboxAborted, relErr)
+	}
+	return res, err
+}
+
+func sandboxExecAs(ctx context.Context, tx *sqlx.Tx, role, query string) (Result, error) {
+	if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE "+pq.QuoteIdentifier(role)); err != nil {
+		return Result{}, err
+	}
+	return sandboxExec(ctx, tx, query)
+}
@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type (
+	// Role is a database role and its attributes. ValidUntil is empty for
+	// passwords that never expire.
+	Role struct {
+		Name        string         `db:"rolname"`
+		Superuser   bool           `db:"rolsuper"`
+		Inherit     bool           `db:"rolinherit"`
+		CreateRole  bool           `db:"rolcreaterole"`
+		CreateDB    bool           `db:"rolcreatedb"`
+		Login       bool           `db:"rolcanlogin"`
+		Replication bool           `db:"rolreplication"`
+		BypassRLS   bool           `db:"rolbypassrls"`
+		ConnLimit   int            `db:"rolconnlimit"`
+		ValidUntil  string         `db:"rolvaliduntil"`
+		MemberOf    pq.StringArray `db:"member_of"`
+		Members     pq.StringArray `db:"members"`
+	}
+
+	// RoleMembership is a role granted to another role.
+	RoleMembership struct {
+		Role        string `db:"role"`
+		Member      string `db:"member"`
+		Grantor     string `db:"grantor"`
+		AdminOption bool   `db:"admin_option"`
+	}
+
+	// PrivilegeObject is a table, schema or function privileges can be
+	// granted on.
+	PrivilegeObject struct {
+		OID    int64  `db:"oid"`
+		Kind   string `db:"kind"`
+		Schema string `db:"schema"`
+		Name   string `db:"name"`
+		Owner  string `db:"owner"`
+	}
+
+	// RolePrivileges are the privileges a role holds on an object. Granted
+	// lines up with Privileges(kind) and includes privileges held through
+	// role membership or PUBLIC, Direct lists those granted to the role
+	// itself.
+	RolePrivileges struct {
+		Role      string         `db:"role"`
+		Superuser bool           `db:"superuser"`
+		Granted   pq.BoolArray   `db:"granted"`
+		Direct    pq.StringArray `db:"direct"`
+	}
+
+	// DefaultPrivilege is a privilege granted on objects Owner creates in
+	// Schema, or in any schema when Schema is empty. ObjectType is one of
+	// table, sequence, function, type or schema.
+	DefaultPrivilege struct {
+		Owner      string         `db:"owner"`
+		Schema     string         `db:"schema"`
+		ObjectType string         `db:"object_type"`
+		Grantee    string         `db:"grantee"`
+		Privileges pq.StringArray `db:"privileges"`
+	}
+)
+
+// Privilege object kinds.
+const (
+	PrivilegeTable    = "table"
+	PrivilegeSchema   = "schema"
+	PrivilegeFunction = "function"
+)
+
+// Attributes lists the role's attributes the way \du does.
+func (r Role) Attributes() []string {
+	var attrs []string
+	for _, a := range []struct {
+		set  bool
+		name string
+	}{
+		{r.Superuser, "superuser"},
+		{r.Login, "login"},
+		{r.CreateRole, "create role"},
+		{r.CreateDB, "create db"},
+		{r.Replication, "replication"},
+		{r.BypassRLS, "bypass rls"},
+		{!r.Inherit, "no inherit"},
+	} {
+		if a.set {
+			attrs = append(attrs, a.name)
+		}
+	}
+	if r.ConnLimit >= 0 {
+		attrs = append(attrs, fmt.Sprintf("%d connections", r.ConnLimit))
+	}
+	return attrs
+}
+
+// Roles returns every role with its attributes and direct memberships,
+// leaving out the predefined pg_ roles.
+func (c *Client) Roles(ctx context.Context) ([]Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT r.rolname, r.rolsuper, r.rolinherit, r.rolcreaterole, r.rolcreatedb, r.rolcanlogin,
+				r.rolreplication, r.rolbypassrls, r.rolconnlimit,
+				CASE WHEN r.rolvaliduntil = 'infinity' THEN 'infinity'
+					ELSE COALESCE(to_char(r.rolvaliduntil, 'YYYY-MM-DD HH24:MI:SS'), '') END AS rolvaliduntil,
+				ARRAY(SELECT g.rolname FROM pg_auth_members m JOIN pg_roles g ON g.oid = m.roleid
+					WHERE m.member = r.oid ORDER BY 1) AS member_of,
+				ARRAY(SELECT u.rolname FROM pg_auth_members m JOIN pg_roles u ON u.oid = m.member
+					WHERE m.roleid = r.oid ORDER BY 1) AS members
+		FROM pg_roles r
+		WHERE r.rolname !~ '^pg_'
+		ORDER BY r.rolname`
+
+	var roles []Role
+	return roles, c.db.SelectContext(ctx, &roles, query)
+}
+
+// RoleMemberships returns every role granted to another role.
+func (c *Client) RoleMemberships(ctx context.Context) ([]RoleMembership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT g.rolname AS role, u.rolname AS member,
+				COALESCE(pg_get_userbyid(m.grantor), '') AS grantor, m.admin_option
+		FROM pg_auth_members m
+		JOIN pg_roles g ON g.oid = m.roleid
+		JOIN pg_roles u ON u.oid = m.member
+		ORDER BY role, member`
+
+	var memberships []RoleMembership
+	return memberships, c.db.SelectContext(ctx, &memberships, query)
+}
+
+// Privileges returns the privileges that can be granted on an object of the
+// given kind.
+func Privileges(kind string) []string {
+	switch kind {
+	case PrivilegeTable:
+		return []string{"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"}
+	case PrivilegeSchema:
+		return []string{"USAGE", "CREATE"}
+	case PrivilegeFunction:
+		return []string{"EXECUTE"}
+	default:
+		return nil
+	}
+}
+
+// privilegeCatalog describes where the ACL of each kind of object is kept.
+var privilegeCatalog = map[string]struct {
+	hasFn string
+	acl   string
+}{
+	PrivilegeTable: {
+		hasFn: "has_table_privilege",
+		acl:   `SELECT COALESCE(c.relacl, acldefault('r', c.relowner)) FROM pg_class c WHERE c.oid = $1::int8::oid`,
+	},
+	PrivilegeSchema: {
+		hasFn: "has_schema_privilege",
+		acl:   `SELECT COALESCE(n.nspacl, acldefault('n', n.nspowner)) FROM pg_namespace n WHERE n.oid = $1::int8::oid`,
+	},
+	PrivilegeFunction: {
+		hasFn: "has_function_privilege",
+		acl:   `SELECT COALESCE(p.proacl, acldefault('f', p.proowner)) FROM pg_proc p WHERE p.oid = $1::int8::oid`,
+	},
+}
+
+// PrivilegeObjects returns the objects of a kind in the user schemas.
+func (c *Client) PrivilegeObjects(ctx context.Context, kind string) ([]PrivilegeObject, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var query string
+	switch kind {
+	case PrivilegeTable:
+		query = `
+		SELECT c.oid::int8 AS oid, 'table' AS kind, n.nspname AS schema, c.relname AS name,
+				pg_get_userbyid(c.relowner) AS owner
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND ` + userSchemas + `
+		ORDER BY schema, name`
+	case PrivilegeSchema:
+		query = `
+		SELECT n.oid::int8 AS oid, 'schema' AS kind, n.nspname AS schema, n.nspname AS name,
+				pg_get_userbyid(n.nspowner) AS owner
+		FROM pg_namespace n
+		WHERE ` + userSchemas + `
+		ORDER BY schema`
+	case PrivilegeFunction:
+		query = `
+		SELECT p.oid::int8 AS oid, 'function' AS kind, n.nspname AS schema,
+				p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')' AS name,
+				pg_get_userbyid(p.proowner) AS owner
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE ` + userSchemas + `
+		ORDER BY schema, name`
+	default:
+		return nil, fmt.Errorf("unknown object kind %q", kind)
+	}
+
+	var objects []PrivilegeObject
+	return objects, c.db.SelectContext(ctx, &objects, query)
+}
+
+// ObjectPrivileges returns the privileges every role, and PUBLIC, holds on
+// an object. Privileges held through membership of another role count as
+// granted, as they do when postgres checks them.
+func (c *Client) ObjectPrivileges(ctx context.Context, obj PrivilegeObject) ([]RolePrivileges, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cat, ok := privilegeCatalog[obj.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown object kind %q", obj.Kind)
+	}
+
+	var granted, public []string
+	for _, p := range Privileges(obj.Kind) {
+		granted = append(granted, fmt.Sprintf("%s(r.oid, $1::int8::oid, '%s')", cat.hasFn, p))
+		public = append(public, fmt.Sprintf("'%s' IN (SELECT privilege_type FROM acl WHERE grantee = 0)", p))
+	}
+
+	query := `
+		WITH acl AS (
+			SELECT a.grantee, a.privilege_type FROM aclexplode((` + cat.acl + `)) a
+		)
+		SELECT r.rolname AS role, r.rolsuper AS superuser,
+				ARRAY[` + strings.Join(granted, ", ") + `] AS granted,
+				ARRAY(SELECT DISTINCT privilege_type FROM acl WHERE acl.grantee = r.oid) AS direct
+		FROM pg_roles r
+		WHERE r.rolname !~ '^pg_'
+		UNION ALL
+		SELECT 'PUBLIC', false,
+				ARRAY[` + strings.Join(public, ", ") + `],
+				ARRAY(SELECT DISTINCT privilege_type FROM acl WHERE grantee = 0)
+		ORDER BY 1`
+
+	var privs []RolePrivileges
+	return privs, c.db.SelectContext(ctx, &privs, query, obj.OID)
+}
+
+// DefaultPrivileges returns the privileges granted on objects when they are
+// created, as set with ALTER DEFAULT PRIVILEGES.
+func (c *Client) DefaultPrivileges(ctx context.Context) ([]DefaultPrivilege, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT pg_get_userbyid(d.defaclrole) AS owner, COALESCE(n.nspname, '') AS schema,
+				CASE d.defaclobjtype WHEN 'r' THEN 'table' WHEN 'S' THEN 'sequence' WHEN 'f' THEN 'function'
+					WHEN 'T' THEN 'type' WHEN 'n' THEN 'schema' ELSE d.defaclobjtype::text END AS object_type,
+				CASE WHEN a.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(a.grantee) END AS grantee,
+				array_agg(a.privilege_type ORDER BY a.privilege_type) AS privileges
+		FROM pg_default_acl d
+		LEFT JOIN pg_namespace n ON n.oid = d.defaclnamespace
+		CROSS JOIN LATERAL aclexplode(d.defaclacl) a
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1, 2, 3, 4`
+
+	var privs []DefaultPrivilege
+	return privs, c.db.SelectContext(ctx, &privs, query)
+}
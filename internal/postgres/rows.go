@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Row is a single table row with its values in column order.
+type Row struct {
+	Columns []string
+	Values  []interface{}
+}
+
+// Value returns the value of the named column.
+func (r Row) Value(column string) (interface{}, bool) {
+	for i, c := range r.Columns {
+		if c == column {
+			return r.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+// Summary renders the row on a single line.
+func (r Row) Summary() string {
+	parts := make([]string, 0, len(r.Columns))
+	for i, c := range r.Columns {
+		parts = append(parts, c+"="+FormatValue(r.Values[i]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Details renders the row as one column per line.
+func (r Row) Details() string {
+	var b strings.Builder
+	for i, c := range r.Columns {
+		fmt.Fprintf(&b, " %s:\t%s\n", c, FormatValue(r.Values[i]))
+	}
+	return b.String()
+}
+
+// FormatValue renders a scanned column value for display.
+func FormatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// Filter restricts rows to those where each of Columns equals the
+// corresponding entry in Values.
+type Filter struct {
+	Columns []string
+	Values  []interface{}
+}
+
+// Rows returns up to limit rows of the given table that match the filter.
+func (c *Client) Rows(ctx context.Context, schema, table string, filter Filter, limit int) ([]Row, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := "SELECT * FROM " + pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(table)
+	var where []string
+	for i, col := range filter.Columns {
+		where = append(where, fmt.Sprintf("%s = $%d", pq.QuoteIdentifier(col), i+1))
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" LIMIT %d", limit)
+
+	rows, err := c.db.QueryxContext(ctx, query, filter.Values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+func scanRows(rows *sqlx.Rows) ([]Row, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Row
+	for rows.Next() {
+		vals, err := rows.SliceScan()
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range vals {
+			// lib/pq hands back the text form of most types as raw bytes, keep
+			// them as strings so they can be passed back in as filter values.
+			if b, ok := v.([]byte); ok && types[i].DatabaseTypeName() != "BYTEA" {
+				vals[i] = string(b)
+			}
+		}
+		out = append(out, Row{Columns: cols, Values: vals})
+	}
+	return out, rows.Err()
+}
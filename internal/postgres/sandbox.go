@@ -0,0 +1,244 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrSandboxAborted is returned when the sandbox transaction can no longer be
+// used, e.g. because the server ended an idle session.
+var ErrSandboxAborted = errors.New("sandbox transaction aborted")
+
+const (
+	// SandboxStatementTimeout bounds every statement run by LimitSandbox, so a
+	// stray query cannot hold its locks for long.
+	SandboxStatementTimeout = "30s"
+	// SandboxLockTimeout bounds how long a statement waits for a lock, so a
+	// sandbox does not queue up behind, and in front of, the application's
+	// own queries.
+	SandboxLockTimeout = "3s"
+	// SandboxIdleTimeout ends a sandbox left idle, releasing the locks its
+	// statements took.
+	SandboxIdleTimeout = "5min"
+)
+
+// Sandbox runs fn inside a transaction that is always rolled back, so fn can
+// make any change it likes without it ever reaching the database.
+func (c *Client) Sandbox(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
+
+// LimitSandbox bounds how long statements in a sandbox may run and wait for
+// locks, and how long the sandbox may sit idle holding them.
+func LimitSandbox(ctx context.Context, tx *sqlx.Tx) error {
+	for _, set := range []string{
+		"SET LOCAL statement_timeout = '" + SandboxStatementTimeout + "'",
+		"SET LOCAL lock_timeout = '" + SandboxLockTimeout + "'",
+		"SET LOCAL idle_in_transaction_session_timeout = '" + SandboxIdleTimeout + "'",
+	} {
+		if _, err := tx.ExecContext(ctx, set); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Result is the outcome of a statement run in a sandbox. Statements that
+// return rows fill Columns and Rows, others report the rows they affected.
+type Result struct {
+	Columns  []string
+	Rows     []Row
+	Affected int64
+}
+
+// SandboxExec runs a statement in a sandbox transaction. The statement runs
+// under a savepoint, so a failing statement does not abort the transaction
+// and the sandbox can be used for the next statement. Statements ending the
+// transaction are refused, and the statement is sent as a prepared statement
+// so a string holding several statements, e.g. "DELETE ...; COMMIT", is
+// refused by the server.
+func SandboxExec(ctx context.Context, tx *sqlx.Tx, query string) (Result, error) {
+	if err := checkSandboxStatement(query); err != nil {
+		return Result{}, err
+	}
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT pgkons_statement"); err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrSandboxAborted, err)
+	}
+	res, err := sandboxExec(ctx, tx, query)
+	if err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT pgkons_statement"); rbErr != nil {
+			return Result{}, fmt.Errorf("%w: %v", ErrSandboxAborted, rbErr)
+		}
+		return Result{}, err
+	}
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT pgkons_statement"); err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrSandboxAborted, err)
+	}
+	return res, nil
+}
+
+func sandboxExec(ctx context.Context, tx *sqlx.Tx, query string) (Result, error) {
+	// lib/pq sends queries without arguments with the simple protocol, which
+	// runs every statement in the string. Preparing goes through the extended
+	// protocol, that takes a single statement only.
+	stmt, err := tx.PreparexContext(ctx, query)
+	if err != nil {
+		return Result{}, err
+	}
+	defer stmt.Close()
+
+	if !returnsRows(query) {
+		res, err := stmt.ExecContext(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		affected, _ := res.RowsAffected()
+		return Result{Affected: affected}, nil
+	}
+
+	rows, err := stmt.QueryxContext(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return Result{}, err
+	}
+	out, err := scanRows(rows)
+	return Result{Columns: cols, Rows: out, Affected: int64(len(out))}, err
+}
+
+// checkSandboxStatement refuses statements that would end the sandbox
+// transaction or step out of its savepoints.
+func checkSandboxStatement(query string) error {
+	fields := strings.Fields(strings.ToLower(stripComments(query)))
+	if len(fields) == 0 {
+		return nil
+	}
+	switch strings.TrimRight(fields[0], ";") {
+	case "begin", "start", "commit", "end", "rollback", "abort", "savepoint", "release":
+		return fmt.Errorf("%s is not allowed, the sandbox transaction is always rolled back", strings.ToUpper(fields[0]))
+	case "prepare":
+		if len(fields) > 1 && strings.TrimRight(fields[1], ";") == "transaction" {
+			return errors.New("PREPARE TRANSACTION is not allowed, the sandbox transaction is always rolled back")
+		}
+	}
+	return nil
+}
+
+// IsDDL reports whether a statement changes the schema. DDL takes locks that
+// are held until the sandbox transaction ends.
+func IsDDL(query string) bool {
+	fields := strings.Fields(strings.ToLower(stripComments(query)))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "create", "drop", "alter", "reindex", "cluster", "truncate", "comment", "grant", "revoke":
+		return true
+	}
+	return false
+}
+
+// IsReadOnly reports whether a statement only reads, so running it takes no
+// locks beyond those of a plain SELECT. A statement it cannot tell apart, or
+// that mentions a writing keyword anywhere, counts as a write.
+func IsReadOnly(query string) bool {
+	words := strings.FieldsFunc(strings.ToLower(stripComments(query)), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '_'
+	})
+	if len(words) == 0 {
+		return true
+	}
+	switch words[0] {
+	case "explain":
+		for _, w := range words {
+			if w == "analyze" || w == "analyse" {
+				return readOnlyWords(words)
+			}
+		}
+		return true
+	case "select", "values", "table", "show", "with":
+		return readOnlyWords(words)
+	}
+	return false
+}
+
+// readOnlyWords reports whether none of words writes or locks rows, e.g. the
+// insert of a WITH or the update of a SELECT ... FOR UPDATE.
+func readOnlyWords(words []string) bool {
+	for _, w := range words {
+		switch w {
+		case "insert", "update", "delete", "merge", "into", "share", "nextval", "setval":
+			return false
+		}
+	}
+	return true
+}
+
+// stripComments removes the comments leading a statement. Block comments
+// nest in postgres.
+func stripComments(query string) string {
+	for {
+		query = strings.TrimSpace(query)
+		switch {
+		case strings.HasPrefix(query, "--"):
+			i := strings.IndexByte(query, '\n')
+			if i < 0 {
+				return ""
+			}
+			query = query[i+1:]
+		case strings.HasPrefix(query, "/*"):
+			depth, i := 0, 0
+			for ; i < len(query)-1; i++ {
+				if query[i] == '/' && query[i+1] == '*' {
+					depth++
+					i++
+				} else if query[i] == '*' && query[i+1] == '/' {
+					depth--
+					i++
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if depth > 0 {
+				return ""
+			}
+			query = query[i+1:]
+		default:
+			return query
+		}
+	}
+}
+
+// returnsRows guesses whether a statement produces a result set from its
+// first keyword, or a RETURNING clause.
+func returnsRows(query string) bool {
+	fields := strings.Fields(strings.ToLower(stripComments(query)))
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.TrimLeft(fields[0], "(") {
+	case "select", "with", "values", "show", "table", "explain", "fetch":
+		return true
+	}
+	for _, f := range fields {
+		if f == "returning" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// Sequence is a sequence from pg_sequences along with the column that owns it,
+// if any. PercentUsed is measured against the smaller of the sequence's own
+// bounds and the bounds of the owning column's type, since an int4 column runs
+// out long before its bigint sequence does.
+type Sequence struct {
+	Schema       string  `db:"schema_name"`
+	Name         string  `db:"sequence_name"`
+	Owner        string  `db:"sequence_owner"`
+	DataType     string  `db:"data_type"`
+	OwnerTable   string  `db:"owner_table"`
+	OwnerColumn  string  `db:"owner_column"`
+	ColumnType   string  `db:"column_type"`
+	Used         bool    `db:"used"`
+	CurrentValue int64   `db:"current_value"`
+	StartValue   int64   `db:"start_value"`
+	Increment    int64   `db:"increment"`
+	MinValue     int64   `db:"min_value"`
+	MaxValue     int64   `db:"max_value"`
+	Cycle        bool    `db:"cycle"`
+	PercentUsed  float64 `db:"percent_used"`
+}
+
+// IsInt4 reports whether the values handed out by the sequence end up in a
+// 4 byte integer.
+func (s Sequence) IsInt4() bool {
+	if s.ColumnType != "" {
+		return s.ColumnType == "integer"
+	}
+	return s.DataType == "integer"
+}
+
+// Sequences returns all sequences ordered by how much of their range has been
+// consumed, most consumed first.
+func (c *Client) Sequences(ctx context.Context) ([]Sequence, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		WITH seqs AS (
+			SELECT s.schemaname AS schema_name, s.sequencename AS sequence_name,
+					s.sequenceowner AS sequence_owner, s.data_type::text AS data_type,
+					COALESCE(quote_ident(tn.nspname) || '.' || quote_ident(t.relname), '') AS owner_table,
+					COALESCE(a.attname::text, '') AS owner_column,
+					COALESCE(format_type(a.atttypid, a.atttypmod), '') AS column_type,
+					s.last_value IS NOT NULL AS used,
+					COALESCE(s.last_value, s.start_value) AS current_value,
+					s.start_value, s.increment_by AS increment, s.min_value, s.max_value, s.cycle,
+					CASE a.atttypid
+						WHEN 'int2'::regtype THEN GREATEST(s.min_value, -32768)
+						WHEN 'int4'::regtype THEN GREATEST(s.min_value, -2147483648)
+						ELSE s.min_value END AS effective_min,
+					CASE a.atttypid
+						WHEN 'int2'::regtype THEN LEAST(s.max_value, 32767)
+						WHEN 'int4'::regtype THEN LEAST(s.max_value, 2147483647)
+						ELSE s.max_value END AS effective_max
+			FROM pg_sequences s
+			JOIN pg_namespace sn ON sn.nspname = s.schemaname
+			JOIN pg_class sc ON sc.relnamespace = sn.oid AND sc.relname = s.sequencename
+			LEFT JOIN pg_depend d ON d.classid = 'pg_class'::regclass AND d.objid = sc.oid
+				AND d.refclassid = 'pg_class'::regclass AND d.deptype IN ('a', 'i')
+			LEFT JOIN pg_class t ON t.oid = d.refobjid
+			LEFT JOIN pg_namespace tn ON tn.oid = t.relnamespace
+			LEFT JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+		)
+		SELECT schema_name, sequence_name, sequence_owner, data_type, owner_table, owner_column,
+				column_type, used, current_value, start_value, increment, min_value, max_value, cycle,
+				COALESCE(CASE
+					WHEN NOT used THEN 0
+					WHEN increment > 0 THEN round(100.0 * (current_value - effective_min) / NULLIF(effective_max - effective_min, 0), 2)
+					ELSE round(100.0 * (effective_max - current_value) / NULLIF(effective_max - effective_min, 0), 2)
+				END, 0) AS percent_used
+		FROM seqs
+		ORDER BY percent_used DESC, schema_name, sequence_name`
+
+	var seqs []Sequence
+	return seqs, c.db.SelectContext(ctx, &seqs, query)
+}
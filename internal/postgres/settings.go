@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type (
+	// Setting is a server configuration parameter from pg_settings.
+	Setting struct {
+		Name           string `db:"name"`
+		Setting        string `db:"setting"`
+		Unit           string `db:"unit"`
+		BootValue      string `db:"boot_val"`
+		ResetValue     string `db:"reset_val"`
+		Source         string `db:"source"`
+		SourceFile     string `db:"sourcefile"`
+		SourceLine     int    `db:"sourceline"`
+		Context        string `db:"context"`
+		Category       string `db:"category"`
+		Description    string `db:"short_desc"`
+		PendingRestart bool   `db:"pending_restart"`
+	}
+
+	// SettingOverride is a parameter set for a role, a database or a role in
+	// a database with ALTER ROLE or ALTER DATABASE. Role or Database is empty
+	// when the override applies to all of them.
+	SettingOverride struct {
+		Database string `db:"database"`
+		Role     string `db:"role"`
+		Name     string `db:"name"`
+		Value    string `db:"value"`
+	}
+)
+
+// Value is the current value of the setting with its unit applied.
+func (s Setting) Value() string {
+	return FormatSetting(s.Setting, s.Unit)
+}
+
+// Default is the value the server uses when the parameter is not set
+// anywhere.
+func (s Setting) Default() string {
+	return FormatSetting(s.BootValue, s.Unit)
+}
+
+// NonDefault reports whether the setting was set anywhere, instead of
+// coming from the server's built-in default.
+func (s Setting) NonDefault() bool {
+	return s.Source != "default" && s.Source != "override"
+}
+
+// Origin describes where the current value came from, telling values set
+// with ALTER SYSTEM apart from those in the configuration file.
+func (s Setting) Origin() string {
+	switch {
+	case strings.HasSuffix(s.SourceFile, "postgresql.auto.conf"):
+		return "ALTER SYSTEM"
+	case s.SourceFile != "":
+		return s.Source + " " + s.SourceFile + ":" + strconv.Itoa(s.SourceLine)
+	case s.Source == "database", s.Source == "user", s.Source == "database user":
+		return s.Source + " override"
+	default:
+		return s.Source
+	}
+}
+
+// RequiresRestart reports whether changing the setting takes a server
+// restart.
+func (s Setting) RequiresRestart() bool {
+	return s.Context == "postmaster"
+}
+
+// FormatSetting renders a raw pg_settings value in its unit, turning memory
+// settings counted in blocks into a size. Empty values are rendered as "".
+func FormatSetting(value, unit string) string {
+	if value == "" {
+		return `""`
+	}
+	if unit == "" {
+		return value
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return value + " " + unit
+	}
+
+	i := strings.IndexFunc(unit, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		return value + " " + unit
+	}
+	factor := int64(1)
+	if i > 0 {
+		factor, _ = strconv.ParseInt(unit[:i], 10, 64)
+	}
+	switch unit[i:] {
+	case "B":
+		return FormatSize(n * factor)
+	case "kB":
+		return FormatSize(n * factor << 10)
+	case "MB":
+		return FormatSize(n * factor << 20)
+	default:
+		return value + " " + unit
+	}
+}
+
+// Settings returns every server configuration parameter visible to the
+// connected role, by category and name.
+func (c *Client) Settings(ctx context.Context) ([]Setting, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT name, COALESCE(setting, '') AS setting, COALESCE(unit, '') AS unit,
+				COALESCE(boot_val, '') AS boot_val, COALESCE(reset_val, '') AS reset_val,
+				source, COALESCE(sourcefile, '') AS sourcefile, COALESCE(sourceline, 0) AS sourceline,
+				context, category, short_desc, pending_restart
+		FROM pg_catalog.pg_settings
+		ORDER BY category, name`
+
+	var settings []Setting
+	return settings, c.db.SelectContext(ctx, &settings, query)
+}
+
+// SettingOverrides returns the parameters set per role and per database.
+func (c *Client) SettingOverrides(ctx context.Context) ([]SettingOverride, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT COALESCE(d.datname, '') AS database, COALESCE(r.rolname, '') AS role,
+				split_part(cfg, '=', 1) AS name, substr(cfg, strpos(cfg, '=') + 1) AS value
+		FROM pg_catalog.pg_db_role_setting s
+		LEFT JOIN pg_catalog.pg_database d ON d.oid = s.setdatabase
+		LEFT JOIN pg_catalog.pg_roles r ON r.oid = s.setrole
+		CROSS JOIN LATERAL unnest(s.setconfig) AS cfg
+		ORDER BY name, database, role`
+
+	var overrides []SettingOverride
+	return overrides, c.db.SelectContext(ctx, &overrides, query)
+}
+
+// SettingDiff is a setting whose value differs between two servers. From or
+// To is empty when only the other server knows the setting.
+type SettingDiff struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// hostSettings are settings that name files or identify the server and are
+// expected to differ between any two servers.
+var hostSettings = map[string]bool{
+	"cluster_name":      true,
+	"config_file":       true,
+	"data_directory":    true,
+	"external_pid_file": true,
+	"hba_file":          true,
+	"ident_file":        true,
+}
+
+// CompareSettings returns the settings whose values differ between from and
+// to, by category and name. Settings naming files or the cluster are left out
+// unless all is set.
+func CompareSettings(from, to []Setting, all bool) []SettingDiff {
+	toByName := make(map[string]Setting, len(to))
+	for _, s := range to {
+		toByName[s.Name] = s
+	}
+
+	var diffs []SettingDiff
+	seen := make(map[string]bool, len(from))
+	for _, f := range from {
+		seen[f.Name] = true
+		if !all && hostSettings[f.Name] {
+			continue
+		}
+		t, ok := toByName[f.Name]
+		if ok && f.Setting == t.Setting && f.Unit == t.Unit {
+			continue
+		}
+		d := SettingDiff{Category: f.Category, Name: f.Name, From: f.Value()}
+		if ok {
+			d.To = t.Value()
+		}
+		diffs = append(diffs, d)
+	}
+	for _, t := range to {
+		if seen[t.Name] || (!all && hostSettings[t.Name]) {
+			continue
+		}
+		diffs = append(diffs, SettingDiff{Category: t.Category, Name: t.Name, To: t.Value()})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Category != diffs[j].Category {
+			return diffs[i].Category < diffs[j].Category
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+// WriteSettingDiffs writes the differences as a table grouped by category,
+// with fromName and toName heading the value columns.
+func WriteSettingDiffs(w io.Writer, fromName, toName string, diffs []SettingDiff) error {
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintln(w, "no differences")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	category := ""
+	for _, d := range diffs {
+		if d.Category != category {
+			if category != "" {
+				fmt.Fprintln(tw, "\t\t\t")
+			}
+			category = d.Category
+			fmt.Fprintf(tw, "%s\t\t\t\n", category)
+			fmt.Fprintf(tw, "  setting\t%s\t%s\t\n", fromName, toName)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t\n", d.Name, orMissing(d.From), orMissing(d.To))
+	}
+	return tw.Flush()
+}
+
+func orMissing(v string) string {
+	if v == "" {
+		return "(missing)"
+	}
+	return v
+}
@@ -0,0 +1,14 @@
+package postgres
+
+import "fmt"
+
+// FormatSize renders a size in bytes the way pg_size_pretty does.
+func FormatSize(b int64) string {
+	units := []string{"bytes", "kB", "MB", "GB", "TB"}
+	v, unit := float64(b), 0
+	for v >= 10240 && unit < len(units)-1 {
+		v /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.0f %s", v, units[unit])
+}
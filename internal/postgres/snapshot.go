@@ -0,0 +1,286 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SnapshotVersion is the version of the snapshot document written by
+// Snapshot. It is bumped whenever the document changes in a way older readers
+// cannot handle.
+const SnapshotVersion = 1
+
+// userSchemas filters a query joined to pg_namespace as n down to the schemas
+// that are not part of postgres itself.
+const userSchemas = `n.nspname NOT IN ('information_schema', 'pg_catalog')
+			AND n.nspname NOT LIKE 'pg_toast%'
+			AND n.nspname NOT LIKE 'pg_temp_%'`
+
+type (
+	// Snapshot is a portable copy of a database's catalog that can be browsed
+	// without a connection to the database.
+	Snapshot struct {
+		Version           int
+		CapturedAt        time.Time
+		ServerVersion     string
+		Database          string
+		Schemas           []Schema
+		Tables            []TableInfo
+		Columns           []TableColumn
+		Constraints       []Constraint
+		Indexes           []Index
+		ForeignKeys       []ForeignKey
+		Views             []View
+		MaterializedViews []View
+		Functions         []Function
+		Sequences         []Sequence
+		Types             []Type
+	}
+
+	TableInfo struct {
+		Schema      string `db:"table_schema"`
+		Name        string `db:"table_name"`
+		Kind        string `db:"kind"`
+		Owner       string `db:"owner"`
+		Comment     string `db:"comment"`
+		RowEstimate int64  `db:"row_estimate"`
+		TableSize   int64  `db:"table_size"`
+		IndexesSize int64  `db:"indexes_size"`
+		TotalSize   int64  `db:"total_size"`
+		SeqScans    int64  `db:"seq_scans"`
+		IndexScans  int64  `db:"index_scans"`
+		LiveTuples  int64  `db:"live_tuples"`
+		DeadTuples  int64  `db:"dead_tuples"`
+	}
+
+	Constraint struct {
+		Schema     string         `db:"table_schema"`
+		Table      string         `db:"table_name"`
+		Name       string         `db:"constraint_name"`
+		Type       string         `db:"constraint_type"`
+		Columns    pq.StringArray `db:"columns"`
+		Definition string         `db:"definition"`
+	}
+
+	Index struct {
+		Schema     string         `db:"table_schema"`
+		Table      string         `db:"table_name"`
+		Name       string         `db:"index_name"`
+		Columns    pq.StringArray `db:"columns"`
+		Unique     bool           `db:"is_unique"`
+		Primary    bool           `db:"is_primary"`
+		Valid      bool           `db:"is_valid"`
+		Size       int64          `db:"index_size"`
+		Scans      int64          `db:"index_scans"`
+		Definition string         `db:"definition"`
+	}
+)
+
+// ReadSnapshot decodes a snapshot document, refusing documents written by a
+// newer version of pgkons.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snap Snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, err
+	}
+	if snap.Version < 1 || snap.Version > SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d, expected at most %d", snap.Version, SnapshotVersion)
+	}
+	return &snap, nil
+}
+
+// WriteSnapshot encodes a snapshot document.
+func WriteSnapshot(w io.Writer, snap *Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(snap)
+}
+
+// Snapshot captures the catalog of the connected database.
+func (c *Client) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap := Snapshot{
+		Version:    SnapshotVersion,
+		CapturedAt: time.Now().UTC(),
+	}
+
+	err := c.db.GetContext(ctx, &snap.ServerVersion, `SHOW server_version`)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.db.GetContext(ctx, &snap.Database, `SELECT current_database()`); err != nil {
+		return nil, err
+	}
+
+	steps := []func() error{
+		func() (err error) { snap.Schemas, err = c.Schemas(ctx); return err },
+		func() (err error) { snap.Tables, err = c.tableInfos(ctx); return err },
+		func() (err error) { snap.Columns, err = c.allColumns(ctx); return err },
+		func() (err error) { snap.Constraints, err = c.constraints(ctx); return err },
+		func() (err error) { snap.Indexes, err = c.Indexes(ctx); return err },
+		func() (err error) { snap.ForeignKeys, err = c.allForeignKeys(ctx); return err },
+		func() (err error) { snap.Views, err = c.allViews(ctx, "v"); return err },
+		func() (err error) { snap.MaterializedViews, err = c.allViews(ctx, "m"); return err },
+		func() (err error) { snap.Functions, err = c.FunctionsUserCreated(ctx); return err },
+		func() (err error) { snap.Sequences, err = c.Sequences(ctx); return err },
+		func() (err error) { snap.Types, err = c.Types(ctx); return err },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+	return &snap, nil
+}
+
+func (c *Client) tableInfos(ctx context.Context) ([]TableInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT n.nspname AS table_schema, c.relname AS table_name,
+				CASE c.relkind
+					WHEN 'p' THEN 'partitioned table'
+					WHEN 'f' THEN 'foreign table'
+					ELSE 'table' END AS kind,
+				pg_get_userbyid(c.relowner) AS owner,
+				COALESCE(obj_description(c.oid, 'pg_class'), '') AS comment,
+				GREATEST(c.reltuples, 0)::bigint AS row_estimate,
+				pg_table_size(c.oid) AS table_size,
+				pg_indexes_size(c.oid) AS indexes_size,
+				pg_total_relation_size(c.oid) AS total_size,
+				COALESCE(s.seq_scan, 0) AS seq_scans,
+				COALESCE(s.idx_scan, 0) AS index_scans,
+				COALESCE(s.n_live_tup, 0) AS live_tuples,
+				COALESCE(s.n_dead_tup, 0) AS dead_tuples
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+		WHERE c.relkind IN ('r', 'p', 'f') AND NOT c.relispartition
+			AND ` + userSchemas + `
+		ORDER BY table_schema, table_name`
+
+	var tables []TableInfo
+	return tables, c.db.SelectContext(ctx, &tables, query)
+}
+
+func (c *Client) allColumns(ctx context.Context) ([]TableColumn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := tableColumnsQuery + `
+			AND ` + userSchemas + `
+		ORDER BY table_schema, table_name, ordinal_position`
+
+	var cols []TableColumn
+	return cols, c.db.SelectContext(ctx, &cols, query)
+}
+
+func (c *Client) allForeignKeys(ctx context.Context) ([]ForeignKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT * FROM (` + foreignKeysQuery + `
+		) fk
+		WHERE table_schema NOT IN ('information_schema', 'pg_catalog')
+		ORDER BY table_schema, table_name, constraint_name`
+
+	var keys []ForeignKey
+	return keys, c.db.SelectContext(ctx, &keys, query)
+}
+
+// allViews reads the views or materialized views, relkind v or m, from
+// pg_class, so views owned by other roles and views that reference no table
+// are captured too. Every view has a row per relation it references.
+func (c *Client) allViews(ctx context.Context, relkind string) ([]View, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT n.nspname AS schema_name, c.relname AS view_name,
+				pg_get_userbyid(c.relowner) AS owner, c.relispopulated AS ispopulated,
+				pg_get_viewdef(c.oid) AS definition,
+				COALESCE(ref.table_schema, '') AS referenced_table_schema,
+				COALESCE(ref.table_name, '') AS referenced_table_name
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN (
+			SELECT DISTINCT r.ev_class, rn.nspname AS table_schema, rc.relname AS table_name
+			FROM pg_rewrite r
+			JOIN pg_depend d ON d.classid = 'pg_rewrite'::regclass AND d.objid = r.oid
+				AND d.refclassid = 'pg_class'::regclass AND d.refobjid <> r.ev_class
+			JOIN pg_class rc ON rc.oid = d.refobjid
+			JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+			WHERE rn.nspname NOT IN ('information_schema', 'pg_catalog')
+		) ref ON ref.ev_class = c.oid
+		WHERE c.relkind = $1 AND ` + userSchemas + `
+		ORDER BY schema_name, view_name, referenced_table_schema, referenced_table_name`
+
+	var views []View
+	return views, c.db.SelectContext(ctx, &views, query, relkind)
+}
+
+func (c *Client) constraints(ctx context.Context) ([]Constraint, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT n.nspname AS table_schema, t.relname AS table_name, co.conname AS constraint_name,
+				CASE co.contype
+					WHEN 'p' THEN 'primary key'
+					WHEN 'u' THEN 'unique'
+					WHEN 'c' THEN 'check'
+					WHEN 'f' THEN 'foreign key'
+					WHEN 'x' THEN 'exclusion'
+					ELSE co.contype::text END AS constraint_type,
+				ARRAY(
+					SELECT a.attname
+					FROM unnest(co.conkey) WITH ORDINALITY k(attnum, ord)
+					JOIN pg_attribute a ON a.attrelid = co.conrelid AND a.attnum = k.attnum
+					ORDER BY k.ord
+				)::text[] AS columns,
+				pg_get_constraintdef(co.oid) AS definition
+		FROM pg_constraint co
+		JOIN pg_class t ON t.oid = co.conrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE co.contype IN ('p', 'u', 'c', 'f', 'x')
+			AND ` + userSchemas + `
+		ORDER BY table_schema, table_name, constraint_name`
+
+	var cons []Constraint
+	return cons, c.db.SelectContext(ctx, &cons, query)
+}
+
+// Indexes returns the indexes on tables outside of the system schemas.
+func (c *Client) Indexes(ctx context.Context) ([]Index, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT n.nspname AS table_schema, t.relname AS table_name, ic.relname AS index_name,
+				ARRAY(
+					SELECT COALESCE(a.attname::text, pg_get_indexdef(i.indexrelid, k.ord::int, true))
+					FROM unnest(i.indkey::int2[]) WITH ORDINALITY k(attnum, ord)
+					LEFT JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum AND k.attnum > 0
+					ORDER BY k.ord
+				)::text[] AS columns,
+				i.indisunique AS is_unique, i.indisprimary AS is_primary, i.indisvalid AS is_valid,
+				pg_relation_size(i.indexrelid) AS index_size,
+				COALESCE(s.idx_scan, 0) AS index_scans,
+				pg_get_indexdef(i.indexrelid) AS definition
+		FROM pg_index i
+		JOIN pg_class ic ON ic.oid = i.indexrelid
+		JOIN pg_class t ON t.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		LEFT JOIN pg_stat_user_indexes s ON s.indexrelid = i.indexrelid
+		WHERE ` + userSchemas + `
+		ORDER BY table_schema, table_name, index_name`
+
+	var idxs []Index
+	return idxs, c.db.SelectContext(ctx, &idxs, query)
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryStat is a normalized query from pg_stat_statements. Times are in
+// milliseconds.
+type QueryStat struct {
+	Username         string  `db:"username"`
+	Database         string  `db:"database"`
+	Query            string  `db:"query"`
+	Calls            int64   `db:"calls"`
+	TotalTime        float64 `db:"total_time"`
+	MeanTime         float64 `db:"mean_time"`
+	Rows             int64   `db:"rows"`
+	SharedBlksHit    int64   `db:"shared_blks_hit"`
+	SharedBlksRead   int64   `db:"shared_blks_read"`
+	TempBlksRead     int64   `db:"temp_blks_read"`
+	TempBlksWritten  int64   `db:"temp_blks_written"`
+	HitPercent       float64 `db:"hit_percent"`
+	PercentTotalTime float64 `db:"percent_total_time"`
+}
+
+// QueryStatOrders are the orders TopQueries can list queries in, mapped to
+// the expression sorted on.
+var QueryStatOrders = map[string]string{
+	"total time":         "total_time",
+	"mean time":          "mean_time",
+	"calls":              "calls",
+	"rows":               "rows",
+	"shared blocks read": "shared_blks_read",
+	"temp blocks":        "temp_blks_read + temp_blks_written",
+}
+
+// TopQueries returns up to limit queries of the connected database from
+// pg_stat_statements in the given order, one of the keys of QueryStatOrders.
+// Queries of other databases are left out, they could not be run against
+// this connection. The extension has to be installed, see HasExtension.
+func (c *Client) TopQueries(ctx context.Context, order string, limit int) ([]QueryStat, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	orderBy, ok := QueryStatOrders[order]
+	if !ok {
+		return nil, fmt.Errorf("unknown order %q", order)
+	}
+
+	var version int
+	if err := c.db.GetContext(ctx, &version, `SELECT current_setting('server_version_num')::int`); err != nil {
+		return nil, err
+	}
+	// postgres 13 split the time columns into planning and execution time
+	totalTime, meanTime := "s.total_time", "s.mean_time"
+	if version >= 130000 {
+		totalTime, meanTime = "s.total_exec_time", "s.mean_exec_time"
+	}
+
+	query := `
+		SELECT COALESCE(r.rolname, '') AS username, COALESCE(d.datname, '') AS database, s.query, s.calls,
+				` + totalTime + ` AS total_time, ` + meanTime + ` AS mean_time, s.rows,
+				s.shared_blks_hit, s.shared_blks_read, s.temp_blks_read, s.temp_blks_written,
+				COALESCE(100.0 * s.shared_blks_hit / NULLIF(s.shared_blks_hit + s.shared_blks_read, 0), 100)::float8 AS hit_percent,
+				COALESCE(100.0 * ` + totalTime + ` / NULLIF(sum(` + totalTime + `) OVER (), 0), 0)::float8 AS percent_total_time
+		FROM pg_stat_statements s
+		LEFT JOIN pg_roles r ON r.oid = s.userid
+		LEFT JOIN pg_database d ON d.oid = s.dbid
+		WHERE s.dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+		ORDER BY ` + orderBy + ` DESC
+		LIMIT $1`
+
+	var stats []QueryStat
+	return stats, c.db.SelectContext(ctx, &stats, query, limit)
+}
+
+var placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+
+// Placeholders returns the numbers of the $n parameters in a normalized
+// query, in ascending order.
+func Placeholders(query string) []int {
+	seen := make(map[int]bool)
+	var nums []int
+	for _, m := range placeholderPattern.FindAllStringSubmatch(query, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || seen[n] {
+			continue
+		}
+		seen[n] = true
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+// FillPlaceholders replaces the $n parameters of a query with the given SQL
+// literals, keyed by parameter number. Parameters without a value are kept.
+func FillPlaceholders(query string, values map[int]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(query, func(p string) string {
+		n, _ := strconv.Atoi(strings.TrimPrefix(p, "$"))
+		if v, ok := values[n]; ok {
+			return v
+		}
+		return p
+	})
+}
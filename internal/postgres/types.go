@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Type is a user defined enum, domain, composite or range type. Only the
+// fields relevant to Kind are populated.
+type Type struct {
+	Schema      string         `db:"schema_name"`
+	Name        string         `db:"type_name"`
+	Kind        string         `db:"kind"`
+	Owner       string         `db:"owner"`
+	Description string         `db:"description"`
+	Labels      pq.StringArray `db:"labels"`
+	BaseType    string         `db:"base_type"`
+	NotNull     bool           `db:"not_null"`
+	Default     string         `db:"default_value"`
+	Constraints pq.StringArray `db:"constraints"`
+	Attributes  pq.StringArray `db:"attributes"`
+	Subtype     string         `db:"subtype"`
+	UsedBy      pq.StringArray `db:"used_by"`
+}
+
+// Types returns the enum, domain, composite and range types outside of the
+// system schemas, together with the columns using them. Composite types that
+// are merely the row type of a table are left out.
+func (c *Client) Types(ctx context.Context) ([]Type, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT n.nspname AS schema_name, t.typname AS type_name,
+				CASE t.typtype
+					WHEN 'e' THEN 'enum'
+					WHEN 'd' THEN 'domain'
+					WHEN 'c' THEN 'composite'
+					WHEN 'r' THEN 'range' END AS kind,
+				pg_get_userbyid(t.typowner) AS owner,
+				COALESCE(obj_description(t.oid, 'pg_type'), '') AS description,
+				ARRAY(
+					SELECT e.enumlabel::text FROM pg_enum e
+					WHERE e.enumtypid = t.oid
+					ORDER BY e.enumsortorder
+				) AS labels,
+				CASE WHEN t.typtype = 'd' THEN format_type(t.typbasetype, t.typtypmod) ELSE '' END AS base_type,
+				t.typnotnull AS not_null,
+				COALESCE(t.typdefault, '') AS default_value,
+				ARRAY(
+					SELECT co.conname || ': ' || pg_get_constraintdef(co.oid) FROM pg_constraint co
+					WHERE co.contypid = t.oid
+					ORDER BY co.conname
+				) AS constraints,
+				ARRAY(
+					SELECT a.attname || ' ' || format_type(a.atttypid, a.atttypmod) FROM pg_attribute a
+					WHERE a.attrelid = t.typrelid AND a.attnum > 0 AND NOT a.attisdropped
+					ORDER BY a.attnum
+				) AS attributes,
+				COALESCE((SELECT format_type(r.rngsubtype, NULL) FROM pg_range r WHERE r.rngtypid = t.oid), '') AS subtype,
+				ARRAY(
+					SELECT DISTINCT quote_ident(un.nspname) || '.' || quote_ident(uc.relname) || '.' || quote_ident(a.attname)
+					FROM pg_attribute a
+					JOIN pg_class uc ON uc.oid = a.attrelid
+					JOIN pg_namespace un ON un.oid = uc.relnamespace
+					WHERE a.atttypid IN (t.oid, t.typarray) AND a.attnum > 0 AND NOT a.attisdropped
+						AND uc.relkind IN ('r', 'p', 'v', 'm', 'f')
+					ORDER BY 1
+				) AS used_by
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		LEFT JOIN pg_class rel ON rel.oid = t.typrelid
+		WHERE t.typtype IN ('e', 'd', 'c', 'r')
+			AND (t.typtype <> 'c' OR rel.relkind = 'c')
+			AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+			AND n.nspname NOT LIKE 'pg_toast%'
+			AND n.nspname NOT LIKE 'pg_temp_%'
+		ORDER BY schema_name, type_name`
+
+	var types []Type
+	return types, c.db.SelectContext(ctx, &types, query)
+}
@@ -0,0 +1,326 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+	"golang.org/x/sys/unix"
+)
+
+// activityInterval is how often the activity monitor refreshes by default.
+const activityInterval = 2 * time.Second
+
+// Activity is a top like view of pg_stat_activity that refreshes until left.
+// Pressing enter picks a backend to cancel or terminate, typing a number sets
+// the refresh interval in seconds and q leaves.
+func (r *Runner) Activity(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	interval := activityInterval
+	for {
+		backends, err := r.pgClient.Activity(ctx)
+		if err != nil {
+			return err
+		}
+		drawActivity(backends, interval)
+
+		input, ok, err := waitForLine(ctx, interval)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		switch input = strings.TrimSpace(input); {
+		case input == "q":
+			clearScreen()
+			return nil
+		case input == "":
+			clearScreen()
+			if err := r.backendActions(ctx, backends); err != nil {
+				return err
+			}
+		default:
+			if secs, err := strconv.Atoi(input); err == nil && secs > 0 {
+				interval = time.Duration(secs) * time.Second
+			}
+		}
+	}
+}
+
+// backendActions offers to cancel or terminate one of the backends.
+func (r *Runner) backendActions(ctx context.Context, backends []postgres.Backend) error {
+	if len(backends) == 0 {
+		return nil
+	}
+	items := make([]backendItem, 0, len(backends))
+	for _, b := range backends {
+		items = append(items, newBackendItem(b))
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .PID | bold | cyan }} {{ .State | bold | green }} {{ .Duration | bold | blue }} {{ .Username }}@{{ .Database }} {{ .Summary | faint }}",
+		Inactive: "  {{ .PID | cyan }} {{ .State | green }} {{ .Duration | blue }} {{ .Username }}@{{ .Database }} {{ .Summary | faint }}",
+		Details: `
+ --------- Backend ----------
+ {{ "Client:" | faint }}	{{ .Application }} {{ .ClientAddr }} ({{ .BackendType }})
+ {{ "Waiting On:" | faint }}	{{ if .WaitEventType }}{{ .WaitEventType }}:{{ .WaitEvent }}{{ else }}-{{ end }}
+ {{ "Blocked By:" | faint }}	{{ if .Blockers }}{{ .Blockers }}{{ else }}-{{ end }}
+ {{ "Query:" | faint }}	{{ .Summary }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		b := items[index]
+		text := strings.ToLower(fmt.Sprintf("%d %s %s %s %s", b.PID, b.Username, b.Database, b.Application, b.Query))
+		return strings.Contains(text, strings.ToLower(input))
+	}
+
+	i, err := selectIndex("Act On Backend", items, searcher, templates)
+	if err != nil {
+		return err
+	}
+	b := items[i]
+
+	if !r.cfg.AllowKill {
+		return viewLines(fmt.Sprintf("backend %d", b.PID), []string{
+			"cancelling and terminating backends is disabled for this profile,",
+			`set "allowKill": true for it in ~/.pgkons/config.json to enable it`,
+		})
+	}
+
+	action, err := selectStr(fmt.Sprintf("backend %d", b.PID), []string{"cancel query", "terminate backend", "back"})
+	overwritePrevLine()
+	if err != nil || action == "back" {
+		return err
+	}
+
+	_, err = (&promptui.Prompt{
+		Label:     fmt.Sprintf("Really %s %d (%s@%s running %s)", action, b.PID, b.Username, b.Database, b.Duration),
+		IsConfirm: true,
+	}).Run()
+	overwritePrevLine()
+	if err != nil {
+		// anything but y declines the confirmation
+		return nil
+	}
+
+	var ok bool
+	if action == "cancel query" {
+		ok, err = r.pgClient.CancelBackend(ctx, b.PID)
+	} else {
+		ok, err = r.pgClient.TerminateBackend(ctx, b.PID)
+	}
+	if err != nil {
+		return viewLines(fmt.Sprintf("backend %d", b.PID), []string{"ERROR: " + err.Error()})
+	}
+	if !ok {
+		return viewLines(fmt.Sprintf("backend %d", b.PID), []string{"the backend no longer exists"})
+	}
+	return nil
+}
+
+type backendItem struct {
+	postgres.Backend
+	Duration string
+	Summary  string
+	Blockers string
+}
+
+func newBackendItem(b postgres.Backend) backendItem {
+	var blockers []string
+	for _, pid := range b.BlockedBy {
+		blockers = append(blockers, strconv.FormatInt(pid, 10))
+	}
+	if b.State == "" {
+		b.State = b.BackendType
+	}
+	return backendItem{
+		Backend:  b,
+		Duration: formatSeconds(b.QuerySeconds),
+		Summary:  strings.Join(strings.Fields(b.Query), " "),
+		Blockers: strings.Join(blockers, ", "),
+	}
+}
+
+func formatSeconds(secs float64) string {
+	return (time.Duration(secs) * time.Second).String()
+}
+
+// drawActivity renders the monitor: backends per state, the blocking chains
+// and the backends themselves, cut to the size of the terminal.
+func drawActivity(backends []postgres.Backend, interval time.Duration) {
+	width, height, err := terminalSize()
+	if err != nil {
+		width, height = 120, 40
+	}
+
+	counts := make(map[string]int)
+	for _, b := range backends {
+		counts[newBackendItem(b).State]++
+	}
+	var states []string
+	for s := range counts {
+		states = append(states, s)
+	}
+	sort.Strings(states)
+	var summary []string
+	for _, s := range states {
+		summary = append(summary, fmt.Sprintf("%s: %d", s, counts[s]))
+	}
+
+	lines := []string{
+		fmt.Sprintf("pgkons activity  %s  every %s  (enter: act on a backend, number + enter: interval, q + enter: leave)", time.Now().Format("15:04:05"), interval),
+		strings.Join(summary, "  "),
+		"",
+	}
+
+	if chains := blockingChains(backends); len(chains) > 0 {
+		lines = append(lines, "blocking chains:")
+		lines = append(lines, chains...)
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, fmt.Sprintf("%-7s %-20s %-10s %-20s %-20s %s", "PID", "STATE", "DURATION", "WAIT", "USER@DB", "QUERY"))
+	for _, b := range backends {
+		item := newBackendItem(b)
+		wait := ""
+		if b.WaitEventType != "" {
+			wait = b.WaitEventType + ":" + b.WaitEvent
+		}
+		lines = append(lines, fmt.Sprintf("%-7d %-20s %-10s %-20s %-20s %s",
+			b.PID, truncate(item.State, 20), item.Duration, truncate(wait, 20), truncate(b.Username+"@"+b.Database, 20), item.Summary))
+	}
+
+	if len(lines) > height-1 {
+		lines = lines[:height-1]
+	}
+	clearScreen()
+	for _, line := range lines {
+		fmt.Fprintln(os.Stderr, truncate(line, width))
+	}
+}
+
+// blockingChains renders the backends blocking others as trees, with the
+// backends at the head of each chain at the root.
+func blockingChains(backends []postgres.Backend) []string {
+	byPID := make(map[int64]postgres.Backend)
+	children := make(map[int64][]int64)
+	for _, b := range backends {
+		byPID[b.PID] = b
+		for _, blocker := range b.BlockedBy {
+			children[blocker] = append(children[blocker], b.PID)
+		}
+	}
+
+	var roots []int64
+	for pid := range children {
+		if b, ok := byPID[pid]; !ok || len(b.BlockedBy) == 0 {
+			roots = append(roots, pid)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i] < roots[j] })
+
+	var (
+		lines   []string
+		visited = make(map[int64]bool)
+		walk    func(pid int64, depth int)
+	)
+	walk = func(pid int64, depth int) {
+		if visited[pid] {
+			return
+		}
+		visited[pid] = true
+
+		prefix := strings.Repeat("  ", depth)
+		if depth > 0 {
+			prefix = strings.Repeat("  ", depth-1) + "└ "
+		}
+		line := fmt.Sprintf("%s%d", prefix, pid)
+		if b, ok := byPID[pid]; ok {
+			item := newBackendItem(b)
+			line += fmt.Sprintf(" %s %s %s@%s %s", item.State, item.Duration, b.Username, b.Database, item.Summary)
+		}
+		lines = append(lines, line)
+		for _, child := range children[pid] {
+			walk(child, depth+1)
+		}
+	}
+	for _, pid := range roots {
+		walk(pid, 0)
+	}
+
+	// backends blocking each other in a cycle have no root, start at the
+	// lowest pid of each cycle.
+	var rest []int64
+	for pid := range children {
+		if !visited[pid] {
+			rest = append(rest, pid)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i] < rest[j] })
+	for _, pid := range rest {
+		walk(pid, 0)
+	}
+	return lines
+}
+
+// waitForLine waits up to timeout for a line on stdin. It reports false when
+// none arrived in time.
+func waitForLine(ctx context.Context, timeout time.Duration) (string, bool, error) {
+	fds := []unix.PollFd{{Fd: int32(os.Stdin.Fd()), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, int(timeout/time.Millisecond))
+	if ctx.Err() != nil {
+		return "", false, ctx.Err()
+	}
+	if err == unix.EINTR {
+		return "", false, nil
+	}
+	if err != nil || n == 0 {
+		return "", false, err
+	}
+
+	// the reader left behind by the last prompt may take the input first, read
+	// without blocking so the monitor keeps refreshing when it does.
+	fd := int(os.Stdin.Fd())
+	if err := unix.SetNonblock(fd, true); err != nil {
+		return "", false, err
+	}
+	defer unix.SetNonblock(fd, false)
+
+	buf := make([]byte, 1024)
+	read, err := unix.Read(fd, buf)
+	if err == unix.EAGAIN || err == unix.EINTR {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(buf[:read]), true, nil
+}
+
+func clearScreen() {
+	fmt.Fprint(os.Stderr, "\033[H\033[2J")
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
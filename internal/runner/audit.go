@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/audit"
+
+	"github.com/jsteenb2/promptui"
+)
+
+type findingItem struct {
+	audit.Finding
+	Level string
+}
+
+// Audit checks the cluster for common security weaknesses and lists the
+// findings, most severe first.
+func (r *Runner) Audit(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	findings, err := audit.Run(ctx, r.pgClient)
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		return selecter("Audit", []string{"no findings, back"}, nil, nil)
+	}
+	items := make([]findingItem, 0, len(findings))
+	for _, f := range findings {
+		items = append(items, findingItem{Finding: f, Level: f.Severity.String()})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   `» {{ if eq .Level "critical" }}{{ .Level | bold | red }}{{ else if eq .Level "warning" }}{{ .Level | bold | yellow }}{{ else }}{{ .Level | bold | faint }}{{ end }} {{ .Object | bold | cyan }} {{ .Check | faint }}`,
+		Inactive: `  {{ if eq .Level "critical" }}{{ .Level | red }}{{ else if eq .Level "warning" }}{{ .Level | yellow }}{{ else }}{{ .Level | faint }}{{ end }} {{ .Object | cyan }} {{ .Check | faint }}`,
+		Details: `
+ --------- Finding ----------
+ {{ "Check:" | faint }}	{{ .Check }}
+ {{ "Object:" | faint }}	{{ .Object }}
+ {{ "Message:" | faint }}	{{ .Message }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		f := items[index]
+		name := strings.Replace(strings.ToLower(f.Level+f.Check+f.Object), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	return selecter("Audit", items, searcher, templates)
+}
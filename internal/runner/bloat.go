@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+type bloatItem struct {
+	postgres.Bloat
+	Name       string
+	Kind       string
+	PrettySize string
+	PrettyReal string
+}
+
+func newBloatItem(b postgres.Bloat) bloatItem {
+	item := bloatItem{
+		Bloat:      b,
+		Name:       b.Table,
+		Kind:       "table",
+		PrettySize: postgres.FormatSize(b.WastedSize),
+		PrettyReal: postgres.FormatSize(b.RealSize),
+	}
+	if b.Index != "" {
+		item.Name, item.Kind = b.Index, "index"
+	}
+	return item
+}
+
+// Bloat lists the estimated wasted space of tables and B-tree indexes. When
+// the pgstattuple extension is installed a selected relation can be measured
+// exactly.
+func (r *Runner) Bloat(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	bloat, err := r.pgClient.Bloat(ctx)
+	if err != nil {
+		return err
+	}
+	if len(bloat) == 0 {
+		return selecter("Bloat", []string{"back"}, nil, nil)
+	}
+	items := make([]bloatItem, 0, len(bloat))
+	for _, b := range bloat {
+		items = append(items, newBloatItem(b))
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Kind | faint }} {{ .Schema | bold | green }}.{{ .Name | bold | cyan }}: {{ .PrettySize | bold | red }} ({{ printf \"%.1f%%\" .WastedPercent | bold | blue }})",
+		Inactive: "  {{ .Kind | faint }} {{ .Schema | green }}.{{ .Name | cyan }}: {{ .PrettySize | red }} ({{ printf \"%.1f%%\" .WastedPercent | blue }})",
+		Details: `
+ --------- Bloat ----------
+ {{ "Table:" | faint }}	{{ .Schema }}.{{ .Table }}
+ {{ "Size:" | faint }}	{{ .PrettyReal }} ({{ .PrettySize }} wasted{{ if .Exact }}, measured{{ else }}, estimated{{ end }})
+ {{ "Fill Factor:" | faint }}	{{ .FillFactor }}{{ if .Unreliable }} {{ "statistics are incomplete, the estimate is unreliable" | yellow }}{{ end }}
+ {{ "Last Vacuum:" | faint }}	{{ .LastVacuum }}
+ {{ "Last Autovacuum:" | faint }}	{{ .LastAutovacuum }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		b := items[index]
+		name := strings.Replace(strings.ToLower(b.Schema+"."+b.Name), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input) || strings.Contains(b.Kind, input)
+	}
+
+	i, err := selectIndex("Bloat (estimated from pg_stats)", items, searcher, templates)
+	if err != nil {
+		return err
+	}
+
+	hasPgstattuple, err := r.pgClient.HasExtension(ctx, "pgstattuple")
+	if err != nil {
+		return err
+	}
+	if !hasPgstattuple {
+		return viewLines(items[i].Schema+"."+items[i].Name, []string{
+			"install the pgstattuple extension to measure bloat exactly:",
+			"    CREATE EXTENSION pgstattuple;",
+		})
+	}
+
+	action, err := selectStr(items[i].Schema+"."+items[i].Name, []string{"measure exactly with pgstattuple (reads the whole relation)", "back"})
+	overwritePrevLine()
+	if err != nil || action == "back" {
+		return err
+	}
+	exact, err := r.pgClient.MeasureBloat(ctx, items[i].Bloat)
+	if err != nil {
+		return err
+	}
+	m := newBloatItem(exact)
+	return viewLines(m.Schema+"."+m.Name, []string{
+		"size:      " + m.PrettyReal,
+		fmt.Sprintf("wasted:    %s (%.1f%%)", m.PrettySize, m.WastedPercent),
+		fmt.Sprintf("estimated: %s (%.1f%%)", items[i].PrettySize, items[i].WastedPercent),
+	})
+}
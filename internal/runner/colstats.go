@@ -0,0 +1,245 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+const (
+	// barWidth is the length of the longest bar in a chart.
+	barWidth = 40
+	// sampleTopValues is how many of the most frequent values a sample
+	// lists.
+	sampleTopValues = 10
+)
+
+// ColumnStatistics profiles the data of a selected column from the planner
+// statistics: null fraction, distinct values, most common values and the
+// histogram. Exact figures can be computed from a sample of the table.
+func (r *Runner) ColumnStatistics(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	tables, err := r.catalog.Tables(ctx)
+	if err != nil {
+		return err
+	}
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Schema | bold | green }}.{{ .Name | bold | cyan }}",
+		Inactive: "  {{ .Schema | green }}.{{ .Name | cyan }}",
+	}
+	searcher := func(input string, index int) bool {
+		t := tables[index]
+		name := strings.Replace(strings.ToLower(t.Schema+"."+t.Name), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	i, err := selectIndex("Column Statistics", tables, searcher, templates)
+	if err != nil {
+		return err
+	}
+	schema, table := tables[i].Schema, tables[i].Name
+
+	cols, err := r.catalog.Columns(ctx, schema)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, c := range cols {
+		if c.Table == table {
+			names = append(names, c.Name)
+		}
+	}
+	if len(names) == 0 {
+		return selecter(schema+"."+table, []string{"no columns, back"}, nil, nil)
+	}
+	column, err := selectStr(schema+"."+table, names)
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+
+	label := schema + "." + table + "." + column
+	stats, err := r.pgClient.ColumnStatistics(ctx, schema, table, column)
+	if err != nil {
+		return err
+	}
+	var lines []string
+	if stats == nil {
+		lines = []string{"no statistics, the table has not been analyzed yet"}
+	} else {
+		lines = columnStatsLines(*stats)
+	}
+	if err := viewLines(label, lines); err != nil {
+		return err
+	}
+
+	const sample = "compute exact values from a sample"
+	action, err := selectStr(label, []string{sample, "back"})
+	overwritePrevLine()
+	if err != nil || action != sample {
+		return err
+	}
+	percent, err := (&promptui.Prompt{
+		Label:     "Percent of the table's pages to sample",
+		Default:   "1",
+		AllowEdit: true,
+		Validate: func(s string) error {
+			p, err := strconv.ParseFloat(s, 64)
+			if err != nil || p <= 0 || p > 100 {
+				return fmt.Errorf("enter a percentage between 0 and 100")
+			}
+			return nil
+		},
+	}).Run()
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+	p, _ := strconv.ParseFloat(percent, 64)
+	s, err := r.pgClient.SampleColumn(ctx, schema, table, column, p, sampleTopValues)
+	if err != nil {
+		return err
+	}
+	return viewLines(label+" (sampled)", columnSampleLines(s))
+}
+
+func columnStatsLines(s postgres.ColumnStats) []string {
+	lines := []string{
+		fmt.Sprintf("rows (estimate):   %d", s.RowEstimate),
+		fmt.Sprintf("null fraction:     %.2f%%", 100*s.NullFrac),
+		fmt.Sprintf("distinct values:   %.0f", s.Distinct()),
+		fmt.Sprintf("average width:     %d bytes", s.AvgWidth),
+	}
+	if s.Correlation != nil {
+		lines = append(lines, fmt.Sprintf("correlation:       %.3f (1 or -1 means the rows are stored in column order)", *s.Correlation))
+	} else {
+		lines = append(lines, "correlation:       unknown")
+	}
+
+	if len(s.MostCommonVals) > 0 {
+		lines = append(lines, "", "most common values:")
+		labels := make([]string, len(s.MostCommonVals))
+		values := make([]float64, len(s.MostCommonVals))
+		for i, v := range s.MostCommonVals {
+			if i < len(s.MostCommonFreqs) {
+				values[i] = s.MostCommonFreqs[i]
+			}
+			labels[i] = fmt.Sprintf("%s (%.2f%%)", v, 100*values[i])
+		}
+		lines = append(lines, barChart(labels, values)...)
+	}
+
+	if len(s.HistogramBounds) > 1 {
+		lines = append(lines, "", "histogram, every bucket holds the same number of rows:")
+		lines = append(lines, histogramLines(s.HistogramBounds)...)
+	}
+	return lines
+}
+
+// histogramLines charts the histogram buckets. For numeric columns a bar is
+// as long as the density of its bucket, narrow buckets hold their rows in a
+// smaller range of values and so have longer bars.
+func histogramLines(bounds []string) []string {
+	labels := make([]string, 0, len(bounds)-1)
+	for i := 0; i < len(bounds)-1; i++ {
+		labels = append(labels, bounds[i]+" … "+bounds[i+1])
+	}
+
+	values := make([]float64, len(labels))
+	numeric := true
+	for i := range labels {
+		lo, errLo := strconv.ParseFloat(bounds[i], 64)
+		hi, errHi := strconv.ParseFloat(bounds[i+1], 64)
+		if errLo != nil || errHi != nil {
+			numeric = false
+			break
+		}
+		if width := hi - lo; width > 0 {
+			values[i] = 1 / width
+		} else {
+			values[i] = math.Inf(1)
+		}
+	}
+	if !numeric {
+		return labels
+	}
+
+	// buckets of a single value are drawn as long as the densest other bucket
+	densest := 0.0
+	for _, v := range values {
+		if !math.IsInf(v, 1) && v > densest {
+			densest = v
+		}
+	}
+	for i, v := range values {
+		if math.IsInf(v, 1) {
+			values[i] = densest
+		}
+	}
+	return barChart(labels, values)
+}
+
+// barChart draws a horizontal bar per label, the largest value spanning
+// barWidth.
+func barChart(labels []string, values []float64) []string {
+	largest, width := 0.0, 0
+	for i, l := range labels {
+		if values[i] > largest {
+			largest = values[i]
+		}
+		if n := len([]rune(l)); n > width {
+			width = n
+		}
+	}
+
+	lines := make([]string, 0, len(labels))
+	for i, l := range labels {
+		n := 0
+		if largest > 0 {
+			n = int(math.Round(values[i] / largest * barWidth))
+		}
+		if n == 0 && values[i] > 0 {
+			n = 1
+		}
+		lines = append(lines, fmt.Sprintf("  %-*s %s", width, l, strings.Repeat("█", n)))
+	}
+	return lines
+}
+
+func columnSampleLines(s postgres.ColumnSample) []string {
+	lines := []string{
+		fmt.Sprintf("sampled rows:      %d (%g%% of pages)", s.Rows, s.Percent),
+		fmt.Sprintf("null fraction:     %s", percentOf(s.Rows-s.NonNull, s.Rows)),
+		fmt.Sprintf("distinct values:   %d in the sample", s.Distinct),
+		fmt.Sprintf("average width:     %.1f bytes", s.AvgWidth),
+	}
+	if len(s.Top) == 0 {
+		return lines
+	}
+
+	lines = append(lines, "", "most frequent values in the sample:")
+	labels := make([]string, 0, len(s.Top))
+	values := make([]float64, 0, len(s.Top))
+	for _, v := range s.Top {
+		labels = append(labels, fmt.Sprintf("%s (%s)", v.Value, percentOf(v.Count, s.Rows)))
+		values = append(values, float64(v.Count))
+	}
+	return append(lines, barChart(labels, values)...)
+}
+
+func percentOf(n, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", 100*float64(n)/float64(total))
+}
@@ -1,12 +1,14 @@
 package runner
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/jsteenb2/promptui"
 )
 
@@ -17,6 +19,9 @@ type CFG struct {
 	Port     string `json:"port"`
 	SSLMode  string `json:"sslMode"`
 	Username string `json:"username"`
+	// AllowKill enables cancelling and terminating backends from the
+	// activity monitor. It is off unless set in the config file.
+	AllowKill bool `json:"allowKill,omitempty"`
 }
 
 func (c CFG) DBConnection() string {
@@ -39,7 +44,27 @@ func (c CFG) DBConnection() string {
 	return strings.Join(parts, " ")
 }
 
+// Open connects to the database described by the configuration.
+func (c CFG) Open(ctx context.Context) (*sqlx.DB, error) {
+	db, err := sqlx.Open("postgres", c.DBConnection())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
 func NewDBCFG() (string, error) {
+	cfg, err := SelectDBCFG()
+	return cfg.DBConnection(), err
+}
+
+// SelectDBCFG asks for one of the saved configurations, or for the details of
+// a new one when none is used.
+func SelectDBCFG() (CFG, error) {
 	cfg, err := func() (CFG, error) {
 		cfgs, err := configFile()
 		if err == nil {
@@ -48,7 +73,7 @@ func NewDBCFG() (string, error) {
 		return CFG{}, err
 	}()
 	if err == nil {
-		return cfg.DBConnection(), nil
+		return cfg, nil
 	}
 
 	var newCFG CFG
@@ -98,7 +123,7 @@ func NewDBCFG() (string, error) {
 	for _, p := range prompts {
 		entry, err := p.prompt.Run()
 		if err != nil {
-			return "", err
+			return CFG{}, err
 		}
 		overwritePrevLine()
 		p.fn(entry)
@@ -107,7 +132,7 @@ func NewDBCFG() (string, error) {
 	sslModes := []string{"disable", "require", "verify-ca", "verify-full"}
 	newCFG.SSLMode, err = selectStr("SSL Mode", sslModes)
 	if err != nil {
-		return "", err
+		return CFG{}, err
 	}
 	overwritePrevLine()
 
@@ -117,7 +142,7 @@ func NewDBCFG() (string, error) {
 	}).Run()
 	overwritePrevLine()
 	if err != nil || confirm != "y" {
-		return newCFG.DBConnection(), nil
+		return newCFG, nil
 	}
 
 	newCFG.Name, err = (&promptui.Prompt{
@@ -130,13 +155,27 @@ func NewDBCFG() (string, error) {
 			fmt.Println(err)
 		}
 	}
-	return newCFG.DBConnection(), nil
+	return newCFG, nil
 }
 
 func LoadConfigs() ([]CFG, error) {
 	return configFile()
 }
 
+// FindConfig returns the saved configuration with the given name.
+func FindConfig(name string) (CFG, error) {
+	cfgs, err := configFile()
+	if err != nil {
+		return CFG{}, err
+	}
+	for _, c := range cfgs {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return CFG{}, fmt.Errorf("no config named %q", name)
+}
+
 func configFile() ([]CFG, error) {
 	konsdir := os.Getenv("HOME") + "/.pgkons"
 	_, err := os.Lstat(konsdir)
@@ -0,0 +1,197 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+var depTypes = map[string]string{
+	"n": "normal",
+	"a": "auto",
+}
+
+type objectItem struct {
+	Label  string
+	object *postgres.Object
+	impact bool
+	cols   bool
+}
+
+// Dependencies lets the user pick a relation, function or type and walk the
+// graph of objects depending on it and objects it depends on.
+func (r *Runner) Dependencies(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	objs, err := r.pgClient.Objects(ctx)
+	if err != nil {
+		return err
+	}
+	obj, err := selectObject("Objects", objs)
+	if err != nil || obj == nil {
+		return err
+	}
+
+	history := []postgres.Object{*obj}
+	for len(history) > 0 {
+		next, err := r.objectNode(ctx, history[len(history)-1])
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			history = history[:len(history)-1]
+			continue
+		}
+		history = append(history, *next)
+	}
+	return nil
+}
+
+// objectNode shows the direct dependents and dependencies of obj and returns
+// the object to move to next, or nil to go back.
+func (r *Runner) objectNode(ctx context.Context, obj postgres.Object) (*postgres.Object, error) {
+	dependents, err := r.pgClient.Dependents(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	dependencies, err := r.pgClient.Dependencies(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []objectItem{
+		{Label: "« back"},
+		{Label: "what breaks if I drop/alter this?", impact: true},
+	}
+	if obj.IsRelation() {
+		items = append(items, objectItem{Label: "columns…", cols: true})
+	}
+	for i, o := range dependents {
+		items = append(items, objectItem{
+			Label:  fmt.Sprintf("← %s %s (%s dependent)", o.Type, o.Identity, depTypes[o.DepType]),
+			object: &dependents[i],
+		})
+	}
+	for i, o := range dependencies {
+		items = append(items, objectItem{
+			Label:  fmt.Sprintf("→ %s %s (%s dependency)", o.Type, o.Identity, depTypes[o.DepType]),
+			object: &dependencies[i],
+		})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Label | bold | cyan }}",
+		Inactive: "  {{ .Label | cyan }}",
+	}
+
+	searcher := func(input string, index int) bool {
+		label := strings.Replace(strings.ToLower(items[index].Label), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(label, input)
+	}
+
+	label := obj.Type + " " + obj.Identity
+	for {
+		i, err := selectIndex(label, items, searcher, templates)
+		if err != nil || i == 0 {
+			return nil, err
+		}
+
+		item := items[i]
+		switch {
+		case item.object != nil:
+			return item.object, nil
+		case item.impact:
+			impact, err := r.pgClient.Impact(ctx, obj)
+			if err != nil {
+				return nil, err
+			}
+			if err := viewLines("Impact of "+label, impactLines(obj, impact)); err != nil {
+				return nil, err
+			}
+		case item.cols:
+			cols, err := r.pgClient.ObjectColumns(ctx, obj)
+			if err != nil {
+				return nil, err
+			}
+			col, err := selectObject("Columns of "+obj.Identity, cols)
+			if err != nil || col != nil {
+				return col, err
+			}
+		}
+	}
+}
+
+// selectObject picks one of objs. A nil object means the user went back.
+func selectObject(label string, objs []postgres.Object) (*postgres.Object, error) {
+	items := []objectItem{{Label: "« back"}}
+	for i, o := range objs {
+		items = append(items, objectItem{Label: o.Type + " " + o.Identity, object: &objs[i]})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Label | bold | cyan }}",
+		Inactive: "  {{ .Label | cyan }}",
+	}
+
+	searcher := func(input string, index int) bool {
+		label := strings.Replace(strings.ToLower(items[index].Label), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(label, input)
+	}
+
+	i, err := selectIndex(label, items, searcher, templates)
+	if err != nil {
+		return nil, err
+	}
+	return items[i].object, nil
+}
+
+// impactLines summarizes what dropping or altering obj would do, followed by
+// the full tree of objects depending on it.
+func impactLines(obj postgres.Object, impact []postgres.Object) []string {
+	if len(impact) == 0 {
+		return []string{"nothing depends on " + obj.Identity + ", it can be dropped or altered freely"}
+	}
+
+	var blocking, views []string
+	for _, o := range impact {
+		if o.Depth == 1 && o.DepType == "n" {
+			blocking = append(blocking, o.Type+" "+o.Identity)
+		}
+		if o.Type == "view" || o.Type == "materialized view" || o.Type == "rule" {
+			views = append(views, o.Type+" "+o.Identity)
+		}
+	}
+
+	var lines []string
+	if len(blocking) > 0 {
+		lines = append(lines, fmt.Sprintf("DROP fails without CASCADE, %d object(s) depend on it:", len(blocking)))
+		for _, b := range blocking {
+			lines = append(lines, "    "+b)
+		}
+	} else {
+		lines = append(lines, "DROP succeeds without CASCADE, only automatically dropped objects depend on it")
+	}
+	lines = append(lines, fmt.Sprintf("DROP ... CASCADE removes %d object(s) in total", len(impact)))
+	if len(views) > 0 {
+		lines = append(lines, fmt.Sprintf("ALTER ... TYPE is blocked until %d view(s) are dropped and recreated:", len(views)))
+		for _, v := range views {
+			lines = append(lines, "    "+v)
+		}
+	}
+
+	lines = append(lines, "", "dependency tree:")
+	for _, o := range impact {
+		lines = append(lines, fmt.Sprintf("%s%s %s (%s)", strings.Repeat("    ", o.Depth), o.Type, o.Identity, depTypes[o.DepType]))
+	}
+	return lines
+}
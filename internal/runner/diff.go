@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+	"github.com/jsteenb2/pgkons/internal/schemadiff"
+
+	"github.com/jsteenb2/promptui"
+)
+
+// ReadSnapshotFile reads a snapshot written by the snapshot command.
+func ReadSnapshotFile(path string) (*postgres.Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return postgres.ReadSnapshot(f)
+}
+
+// LoadSnapshot reads source as a snapshot file when such a file exists, and
+// otherwise captures a snapshot from the saved configuration named source.
+func LoadSnapshot(ctx context.Context, source string) (*postgres.Snapshot, error) {
+	if _, err := os.Stat(source); err == nil {
+		return ReadSnapshotFile(source)
+	}
+
+	cfg, err := FindConfig(source)
+	if err != nil {
+		return nil, err
+	}
+	db, err := cfg.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return postgres.New(db).Snapshot(ctx)
+}
+
+const (
+	currentSource  = "current connection"
+	snapshotSource = "snapshot file…"
+)
+
+// selectSource asks for a saved configuration or snapshot file and loads a
+// snapshot from it.
+func (r *Runner) selectSource(ctx context.Context, label string) (*postgres.Snapshot, error) {
+	var sources []string
+	if !r.offline() {
+		sources = append(sources, currentSource)
+	}
+	cfgs, err := LoadConfigs()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cfgs {
+		sources = append(sources, c.Name)
+	}
+	sources = append(sources, snapshotSource)
+
+	source, err := selectStr(label, sources)
+	if err != nil {
+		return nil, err
+	}
+	overwritePrevLine()
+
+	switch source {
+	case currentSource:
+		return r.pgClient.Snapshot(ctx)
+	case snapshotSource:
+		path, err := (&promptui.Prompt{
+			Label:    "Snapshot file",
+			Validate: validateEmptyInput("file"),
+		}).Run()
+		overwritePrevLine()
+		if err != nil {
+			return nil, err
+		}
+		return ReadSnapshotFile(path)
+	default:
+		return LoadSnapshot(ctx, source)
+	}
+}
+
+// Diff compares two databases or snapshots and lists what changed between
+// them.
+func (r *Runner) Diff(ctx context.Context) error {
+	from, err := r.selectSource(ctx, "Compare from")
+	if err != nil {
+		return err
+	}
+	to, err := r.selectSource(ctx, "Compare to")
+	if err != nil {
+		return err
+	}
+	return viewChanges(schemadiff.Compare(from, to))
+}
+
+func viewChanges(changes []schemadiff.Change) error {
+	if len(changes) == 0 {
+		return selecter("Differences", []string{"no differences"}, nil, nil)
+	}
+
+	items := []changeItem{{Label: "« back"}}
+	for i, c := range changes {
+		items = append(items, changeItem{Label: c.String(), Change: &changes[i]})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   `» {{ with .Change }}{{ if eq .Kind "added" }}{{ "+" | green }}{{ else if eq .Kind "removed" }}{{ "-" | red }}{{ else }}{{ "~" | yellow }}{{ end }} {{ .Object | bold | blue }} {{ .Name | bold | cyan }}{{ else }}{{ .Label | bold | cyan }}{{ end }}`,
+		Inactive: `  {{ with .Change }}{{ if eq .Kind "added" }}{{ "+" | green }}{{ else if eq .Kind "removed" }}{{ "-" | red }}{{ else }}{{ "~" | yellow }}{{ end }} {{ .Object | blue }} {{ .Name | cyan }}{{ else }}{{ .Label | cyan }}{{ end }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		label := strings.Replace(strings.ToLower(items[index].Label), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(label, input)
+	}
+
+	for {
+		i, err := selectIndex("Differences", items, searcher, templates)
+		if err != nil || i == 0 {
+			return err
+		}
+		c := *items[i].Change
+		if err := viewLines(c.String(), changeLines(c)); err != nil {
+			return err
+		}
+	}
+}
+
+// changeItem is an entry of the differences list, either a change or the
+// back entry.
+type changeItem struct {
+	Label  string
+	Change *schemadiff.Change
+}
+
+func changeLines(c schemadiff.Change) []string {
+	lines := append([]string{c.String()}, c.Details...)
+	if c.Before != "" {
+		lines = append(lines, "", "before:")
+		lines = append(lines, strings.Split(c.Before, "\n")...)
+	}
+	if c.After != "" {
+		lines = append(lines, "", "after:")
+		lines = append(lines, strings.Split(c.After, "\n")...)
+	}
+	return lines
+}
@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/erd"
+
+	"github.com/jsteenb2/promptui"
+)
+
+// ERDiagram renders an entity-relationship diagram of a schema, or of some of
+// its tables, to a file or the screen.
+func (r *Runner) ERDiagram(ctx context.Context) error {
+	schemas, err := r.catalog.Schemas(ctx)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, s := range schemas {
+		names = append(names, s.Name)
+	}
+	schema, err := selectStr("Schema", names)
+	if err != nil {
+		return err
+	}
+	overwritePrevLine()
+
+	cols, err := r.catalog.Columns(ctx, schema)
+	if err != nil {
+		return err
+	}
+	keys, err := r.catalog.SchemaForeignKeys(ctx, schema)
+	if err != nil {
+		return err
+	}
+
+	var tables []string
+	for _, c := range cols {
+		if len(tables) == 0 || tables[len(tables)-1] != c.Table {
+			tables = append(tables, c.Table)
+		}
+	}
+	include, err := selectTables(tables)
+	if err != nil {
+		return err
+	}
+
+	var formats []string
+	for _, f := range erd.Formats {
+		formats = append(formats, string(f))
+	}
+	format, err := selectStr("Format", formats)
+	if err != nil {
+		return err
+	}
+	overwritePrevLine()
+
+	var buf bytes.Buffer
+	if err := erd.Write(&buf, erd.New(cols, keys, include), erd.Format(format)); err != nil {
+		return err
+	}
+	return writeOrView("ER Diagram", buf.Bytes())
+}
+
+type tableChoice struct {
+	Label    string
+	Selected bool
+}
+
+// selectTables lets the user toggle tables on and off until done is picked.
+// Picking done without any tables selected means all of them.
+func selectTables(tables []string) ([]string, error) {
+	items := []tableChoice{{Label: "done"}}
+	for _, t := range tables {
+		items = append(items, tableChoice{Label: t})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   `» {{ if .Selected }}{{ "✔" | green }}{{ else }} {{ end }} {{ .Label | bold | cyan }}`,
+		Inactive: `  {{ if .Selected }}{{ "✔" | green }}{{ else }} {{ end }} {{ .Label | cyan }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		label := strings.Replace(strings.ToLower(items[index].Label), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(label, input)
+	}
+
+	for {
+		i, err := selectIndex("Tables (none selected means all)", items, searcher, templates)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			items[i].Selected = !items[i].Selected
+			continue
+		}
+
+		var selected []string
+		for _, item := range items[1:] {
+			if item.Selected {
+				selected = append(selected, item.Label)
+			}
+		}
+		sort.Strings(selected)
+		return selected, nil
+	}
+}
+
+// writeOrView asks for a file to write out to and shows out on screen when
+// no file is given.
+func writeOrView(label string, out []byte) error {
+	path, err := (&promptui.Prompt{
+		Label: "Output file (empty shows it on screen)",
+	}).Run()
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return viewLines(label, strings.Split(strings.TrimRight(string(out), "\n"), "\n"))
+	}
+	return ioutil.WriteFile(path, out, 0644)
+}
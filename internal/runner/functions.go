@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+func (r *Runner) Functions(ctx context.Context) error {
+	fns, err := r.catalog.Functions(ctx)
+	if err != nil {
+		return err
+	}
+	return r.functions(ctx, "Functions", fns)
+}
+
+func (r *Runner) FunctionsUserCreated(ctx context.Context) error {
+	fns, err := r.catalog.FunctionsUserCreated(ctx)
+	if err != nil {
+		return err
+	}
+	return r.functions(ctx, "User Created Functions", fns)
+}
+
+func (r *Runner) functions(ctx context.Context, label string, fns []postgres.Function) error {
+	if len(fns) == 0 {
+		return selecter(label, []string{"back"}, nil, nil)
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Schema | bold | green }}.{{ .Name | bold | cyan }}({{ .Arguments }}) {{ .Kind | faint }}",
+		Inactive: "  {{ .Schema | green }}.{{ .Name | cyan }}({{ .Arguments }}) {{ .Kind | faint }}",
+		Details: `
+ --------- Function ----------
+ {{ "Signature:" | faint }}	{{ .Signature }}
+ {{ "Returns:" | faint }}	{{ .Result }}
+ {{ "Kind:" | faint }}	{{ .Kind }}
+ {{ "Language:" | faint }}	{{ .Language }}
+ {{ "Volatility:" | faint }}	{{ .Volatility }}
+ {{ "Security Definer:" | faint }}	{{ .SecurityDefiner }}
+ {{ "Owner:" | faint }}	{{ .Owner }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		fn := fns[index]
+		name := strings.Replace(strings.ToLower(fn.Name), " ", "", -1)
+		schema := strings.Replace(strings.ToLower(fn.Schema), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(schema+"."+name, input)
+	}
+
+	i, err := selectIndex(label, fns, searcher, templates)
+	if err != nil {
+		return err
+	}
+	return viewSource(fns[i].Signature(), fns[i].Source)
+}
+
+type sourceLine struct {
+	Number int
+	Text   string
+	plain  string
+}
+
+// viewSource shows source code in a scrollable, syntax highlighted list of
+// lines. Selecting any line returns.
+func viewSource(title, src string) error {
+	plain := strings.Split(strings.Replace(src, "\t", "    ", -1), "\n")
+	var lines []sourceLine
+	for i, l := range highlightSQL(src) {
+		lines = append(lines, sourceLine{Number: i + 1, Text: l, plain: plain[i]})
+	}
+
+	width := len(fmt.Sprint(len(lines)))
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   fmt.Sprintf(`» {{ printf "%%%dd" .Number | faint }} {{ .Text }}`, width),
+		Inactive: fmt.Sprintf(`  {{ printf "%%%dd" .Number | faint }} {{ .Text }}`, width),
+	}
+
+	searcher := func(input string, index int) bool {
+		return strings.Contains(strings.ToLower(lines[index].plain), strings.ToLower(input))
+	}
+
+	return selecter(title, lines, searcher, templates)
+}
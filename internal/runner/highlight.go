@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/jsteenb2/promptui"
+)
+
+var (
+	keywordStyle = promptui.Styler(promptui.FGBlue, promptui.FGBold)
+	stringStyle  = promptui.Styler(promptui.FGGreen)
+	numberStyle  = promptui.Styler(promptui.FGMagenta)
+	commentStyle = promptui.Styler(promptui.FGFaint)
+)
+
+var sqlKeywords = func() map[string]bool {
+	words := `
+		ALTER AND AS ASC BEGIN BETWEEN BY CASE CAST CLOSE COMMIT CONSTANT CONTINUE CREATE
+		CURSOR DECLARE DEFAULT DELETE DESC DIAGNOSTICS DISTINCT DO DROP ELSE ELSIF END
+		EXCEPTION EXECUTE EXISTS EXIT FETCH FOR FOREACH FOUND FROM FULL FUNCTION GET
+		GROUP HAVING IF ILIKE IMMUTABLE IN INNER INSERT INTO IS JOIN LANGUAGE LATERAL
+		LEFT LIKE LIMIT LOOP NEW NOT NOTICE NULL OF OFFSET OLD ON OPEN OR ORDER OUT
+		OUTER PERFORM PROCEDURE QUERY RAISE RECORD REPLACE RETURN RETURNING RETURNS
+		RIGHT ROLLBACK ROWTYPE SECURITY SELECT SET SETOF STABLE STRICT TABLE THEN TO
+		TRIGGER TYPE UNION UPDATE USING VALUES VOLATILE WHEN WHERE WHILE WITH`
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		m[w] = true
+	}
+	return m
+}()
+
+// highlightSQL colors keywords, literals and comments of SQL and PL/pgSQL
+// source and returns it split into lines. Each line carries its own escape
+// codes so the lines can be rendered independently.
+func highlightSQL(src string) []string {
+	src = strings.Replace(src, "\t", "    ", -1)
+
+	var b strings.Builder
+	emit := func(text string, style func(interface{}) string) {
+		if style == nil {
+			b.WriteString(text)
+			return
+		}
+		for i, part := range strings.Split(text, "\n") {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			if part != "" {
+				b.WriteString(style(part))
+			}
+		}
+	}
+
+	for i := 0; i < len(src); {
+		rest := src[i:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			emit(rest[:end], commentStyle)
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+			emit(rest[:end], commentStyle)
+			i += end
+		case rest[0] == '\'':
+			end := 1
+			for end < len(rest) {
+				if rest[end] == '\'' {
+					if end+1 < len(rest) && rest[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					end++
+					break
+				}
+				end++
+			}
+			emit(rest[:end], stringStyle)
+			i += end
+		case rest[0] == '$':
+			end := 1
+			for end < len(rest) && isWordByte(rest[end]) {
+				end++
+			}
+			if end < len(rest) && rest[end] == '$' {
+				end++
+				emit(rest[:end], stringStyle)
+			} else {
+				emit(rest[:end], nil)
+			}
+			i += end
+		case rest[0] >= '0' && rest[0] <= '9':
+			end := 1
+			for end < len(rest) && (rest[end] >= '0' && rest[end] <= '9' || rest[end] == '.') {
+				end++
+			}
+			emit(rest[:end], numberStyle)
+			i += end
+		case isWordByte(rest[0]):
+			end := 1
+			for end < len(rest) && isWordByte(rest[end]) {
+				end++
+			}
+			word := rest[:end]
+			if sqlKeywords[strings.ToUpper(word)] {
+				emit(word, keywordStyle)
+			} else {
+				emit(word, nil)
+			}
+			i += end
+		default:
+			emit(rest[:1], nil)
+			i++
+		}
+	}
+
+	return strings.Split(b.String(), "\n")
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+type indexIssueItem struct {
+	postgres.IndexIssue
+	PrettySize string
+}
+
+// IndexHealth lists the index issues found in the database. Selecting one
+// offers to try its suggestion in the playground.
+func (r *Runner) IndexHealth(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	report, err := r.pgClient.IndexHealth(ctx)
+	if err != nil {
+		return err
+	}
+	if len(report.Issues) == 0 {
+		return selecter("Index Health", []string{"no index issues found, back"}, nil, nil)
+	}
+
+	items := make([]indexIssueItem, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		items = append(items, indexIssueItem{IndexIssue: issue, PrettySize: postgres.FormatSize(issue.Size)})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Kind | bold | red }} {{ .Schema | bold | green }}.{{ .Index | bold | cyan }} ({{ .PrettySize | bold | blue }})",
+		Inactive: "  {{ .Kind | red }} {{ .Schema | green }}.{{ .Index | cyan }} ({{ .PrettySize | blue }})",
+		Details: `
+ --------- Index ----------
+ {{ "Table:" | faint }}	{{ .Schema }}.{{ .Table }}
+ {{ "Scans:" | faint }}	{{ .Scans }}
+ {{ "Why:" | faint }}	{{ .Reason }}
+ {{ "Definition:" | faint }}	{{ if .Definition }}{{ .Definition }}{{ else }}-{{ end }}
+ {{ "Suggestion:" | faint }}	{{ .Suggestion | yellow }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		issue := items[index]
+		name := strings.Replace(strings.ToLower(issue.Schema+"."+issue.Table+"."+issue.Index), " ", "", -1)
+		kind := strings.Replace(issue.Kind, " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input) || strings.Contains(kind, input)
+	}
+
+	i, err := selectIndex("Index Health (scans since "+report.StatsReset+")", items, searcher, templates)
+	if err != nil {
+		return err
+	}
+
+	action, err := selectStr(items[i].Suggestion, []string{"try in playground", "back"})
+	overwritePrevLine()
+	if err != nil || action != "try in playground" {
+		return err
+	}
+	return r.Playground(ctx, items[i].Suggestion)
+}
@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+const (
+	// cacheHitWarnPercent is the hit ratio below which a table or index is
+	// flagged as reading from disk too often.
+	cacheHitWarnPercent = 99.0
+
+	// seqScanWarnRows is the row count above which a table answering most of
+	// its queries with sequential scans is flagged.
+	seqScanWarnRows = 10000
+)
+
+type tableIOItem struct {
+	postgres.TableIO
+	PrettySize string
+	Warnings   string
+}
+
+// TablesByCacheHit lists tables with the lowest heap cache hit ratio first.
+func (r *Runner) TablesByCacheHit(ctx context.Context) error {
+	return r.tableIO(ctx, "Tables By Cache Hit Ratio", nil)
+}
+
+// TablesBySeqScans lists tables with the most rows read by sequential scans
+// first.
+func (r *Runner) TablesBySeqScans(ctx context.Context) error {
+	return r.tableIO(ctx, "Tables By Sequential Scans", func(a, b postgres.TableIO) bool {
+		return a.SeqTupRead > b.SeqTupRead
+	})
+}
+
+func (r *Runner) tableIO(ctx context.Context, label string, less func(a, b postgres.TableIO) bool) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	tables, err := r.pgClient.TableIO(ctx)
+	if err != nil {
+		return err
+	}
+	if less != nil {
+		sort.SliceStable(tables, func(i, j int) bool { return less(tables[i], tables[j]) })
+	}
+
+	var hit, read int64
+	items := make([]tableIOItem, 0, len(tables))
+	for _, t := range tables {
+		hit, read = hit+t.HeapHit, read+t.HeapRead
+
+		var warnings []string
+		if t.HeapHitPercent < cacheHitWarnPercent {
+			warnings = append(warnings, fmt.Sprintf("heap hit ratio below %.0f%%", cacheHitWarnPercent))
+		}
+		if t.LiveTuples >= seqScanWarnRows && t.SeqScans > t.IdxScans {
+			warnings = append(warnings, fmt.Sprintf("mostly sequential scans over %d rows", t.LiveTuples))
+		}
+		items = append(items, tableIOItem{
+			TableIO:    t,
+			PrettySize: postgres.FormatSize(t.Size),
+			Warnings:   strings.Join(warnings, ", "),
+		})
+	}
+	if len(items) == 0 {
+		return selecter(label, []string{"back"}, nil, nil)
+	}
+	if hit+read > 0 {
+		label = fmt.Sprintf("%s (overall heap hit ratio %.2f%%)", label, 100*float64(hit)/float64(hit+read))
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ if .Warnings }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | bold | green }}.{{ .Table | bold | cyan }}: heap {{ printf \"%.2f%%\" .HeapHitPercent | bold | blue }}, {{ .SeqScans | bold | blue }} seq / {{ .IdxScans | bold | blue }} idx scans",
+		Inactive: "  {{ if .Warnings }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | green }}.{{ .Table | cyan }}: heap {{ printf \"%.2f%%\" .HeapHitPercent | blue }}, {{ .SeqScans | blue }} seq / {{ .IdxScans | blue }} idx scans",
+		Details: `
+ --------- I/O ----------
+ {{ "Size:" | faint }}	{{ .PrettySize }}, {{ .LiveTuples }} live rows
+ {{ "Heap Blocks:" | faint }}	{{ .HeapHit }} hit, {{ .HeapRead }} read ({{ printf "%.2f%%" .HeapHitPercent }} hit)
+ {{ "Index Blocks:" | faint }}	{{ .IdxHit }} hit, {{ .IdxRead }} read ({{ printf "%.2f%%" .IdxHitPercent }} hit)
+ {{ "Scans:" | faint }}	{{ .SeqScans }} seq reading {{ .SeqTupRead }} rows, {{ .IdxScans }} idx ({{ printf "%.1f%%" .IdxScanPercent }} idx)
+ {{ "Updates:" | faint }}	{{ .Updates }}, {{ .HotUpdates }} HOT ({{ printf "%.1f%%" .HotPercent }})
+ {{ "Warnings:" | faint }}	{{ if .Warnings }}{{ .Warnings | red }}{{ else }}-{{ end }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		t := items[index]
+		name := strings.Replace(strings.ToLower(t.Schema+"."+t.Table), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	return selecter(label, items, searcher, templates)
+}
+
+type indexIOItem struct {
+	postgres.IndexIO
+	PrettySize string
+	Warning    bool
+}
+
+// IndexesByCacheHit lists indexes with the lowest cache hit ratio first.
+func (r *Runner) IndexesByCacheHit(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	indexes, err := r.pgClient.IndexIO(ctx)
+	if err != nil {
+		return err
+	}
+
+	label := "Indexes By Cache Hit Ratio"
+	var hit, read int64
+	items := make([]indexIOItem, 0, len(indexes))
+	for _, idx := range indexes {
+		hit, read = hit+idx.Hit, read+idx.Read
+		items = append(items, indexIOItem{
+			IndexIO:    idx,
+			PrettySize: postgres.FormatSize(idx.Size),
+			Warning:    idx.HitPercent < cacheHitWarnPercent,
+		})
+	}
+	if len(items) == 0 {
+		return selecter(label, []string{"back"}, nil, nil)
+	}
+	if hit+read > 0 {
+		label = fmt.Sprintf("%s (overall index hit ratio %.2f%%)", label, 100*float64(hit)/float64(hit+read))
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ if .Warning }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | bold | green }}.{{ .Index | bold | cyan }}: {{ printf \"%.2f%%\" .HitPercent | bold | blue }} ({{ .PrettySize | bold | blue }})",
+		Inactive: "  {{ if .Warning }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | green }}.{{ .Index | cyan }}: {{ printf \"%.2f%%\" .HitPercent | blue }} ({{ .PrettySize | blue }})",
+		Details: `
+ --------- I/O ----------
+ {{ "Table:" | faint }}	{{ .Schema }}.{{ .Table }}
+ {{ "Blocks:" | faint }}	{{ .Hit }} hit, {{ .Read }} read
+ {{ "Scans:" | faint }}	{{ .Scans }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		idx := items[index]
+		name := strings.Replace(strings.ToLower(idx.Schema+"."+idx.Table+"."+idx.Index), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	return selecter(label, items, searcher, templates)
+}
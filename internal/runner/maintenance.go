@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+// wraparoundWarnPercent is how far towards wraparound a table or database
+// may get before it is flagged.
+const wraparoundWarnPercent = 50.0
+
+type maintenanceItem struct {
+	postgres.TableMaintenance
+	Wraparound float64
+	Warnings   string
+}
+
+// TablesByDeadTuples lists tables with the most dead tuples first.
+func (r *Runner) TablesByDeadTuples(ctx context.Context) error {
+	return r.maintenance(ctx, "Tables By Dead Tuples", func(a, b postgres.TableMaintenance) bool {
+		return a.DeadTuples > b.DeadTuples
+	})
+}
+
+// TablesByTransactionAge lists tables closest to wraparound first.
+func (r *Runner) TablesByTransactionAge(ctx context.Context) error {
+	return r.maintenance(ctx, "Tables By Transaction Age", func(a, b postgres.TableMaintenance) bool {
+		return a.XIDAge > b.XIDAge
+	})
+}
+
+func (r *Runner) maintenance(ctx context.Context, label string, less func(a, b postgres.TableMaintenance) bool) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	tables, err := r.pgClient.Maintenance(ctx)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(tables, func(i, j int) bool { return less(tables[i], tables[j]) })
+
+	items := make([]maintenanceItem, 0, len(tables))
+	for _, t := range tables {
+		var warnings []string
+		if !t.AutovacuumEnabled {
+			warnings = append(warnings, "autovacuum disabled")
+		}
+		if t.NeedsVacuum() {
+			warnings = append(warnings, "due for vacuum")
+		}
+		if t.NeedsAnalyze() {
+			warnings = append(warnings, "due for analyze")
+		}
+		if t.XIDAge > t.FreezeMaxAge {
+			warnings = append(warnings, "anti-wraparound vacuum due")
+		}
+		if pct := t.WraparoundPercent(); pct >= wraparoundWarnPercent {
+			warnings = append(warnings, fmt.Sprintf("%.1f%% towards wraparound", pct))
+		}
+		items = append(items, maintenanceItem{
+			TableMaintenance: t,
+			Wraparound:       t.WraparoundPercent(),
+			Warnings:         strings.Join(warnings, ", "),
+		})
+	}
+	if len(items) == 0 {
+		return selecter(label, []string{"back"}, nil, nil)
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ if .Warnings }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | bold | green }}.{{ .Table | bold | cyan }}: {{ .DeadTuples | bold | blue }} dead, age {{ .XIDAge | bold | blue }} ({{ printf \"%.1f%%\" .Wraparound | bold | blue }})",
+		Inactive: "  {{ if .Warnings }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | green }}.{{ .Table | cyan }}: {{ .DeadTuples | blue }} dead, age {{ .XIDAge | blue }} ({{ printf \"%.1f%%\" .Wraparound | blue }})",
+		Details: `
+ --------- Maintenance ----------
+ {{ "Tuples:" | faint }}	{{ .LiveTuples }} live, {{ .DeadTuples }} dead, vacuum threshold {{ .VacuumThreshold }}
+ {{ "Modified Since Analyze:" | faint }}	{{ .ModSinceAnalyze }}, analyze threshold {{ .AnalyzeThreshold }}
+ {{ "Vacuum:" | faint }}	{{ .LastVacuum }} (auto {{ .LastAutovacuum }})
+ {{ "Analyze:" | faint }}	{{ .LastAnalyze }} (auto {{ .LastAutoanalyze }})
+ {{ "Transaction Age:" | faint }}	{{ .XIDAge }} of {{ .FreezeMaxAge }} freeze max age{{ if .ReloptionOverrides }}, reloptions: {{ .ReloptionOverrides }}{{ end }}
+ {{ "Warnings:" | faint }}	{{ if .Warnings }}{{ .Warnings | red }}{{ else }}-{{ end }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		t := items[index]
+		name := strings.Replace(strings.ToLower(t.Schema+"."+t.Table), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	return selecter(label, items, searcher, templates)
+}
+
+type databaseAgeItem struct {
+	postgres.DatabaseAge
+	Wraparound float64
+	Warning    bool
+}
+
+// DatabasesByTransactionAge lists the databases of the server closest to
+// wraparound first.
+func (r *Runner) DatabasesByTransactionAge(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	dbs, err := r.pgClient.DatabaseAges(ctx)
+	if err != nil {
+		return err
+	}
+	items := make([]databaseAgeItem, 0, len(dbs))
+	for _, d := range dbs {
+		pct := d.WraparoundPercent()
+		items = append(items, databaseAgeItem{
+			DatabaseAge: d,
+			Wraparound:  pct,
+			Warning:     pct >= wraparoundWarnPercent || d.XIDAge > d.FreezeMaxAge,
+		})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ if .Warning }}{{ \"⚠\" | red }} {{ end }}{{ .Name | bold | cyan }}: age {{ .XIDAge | bold | blue }} ({{ printf \"%.1f%%\" .Wraparound | bold | blue }} towards wraparound)",
+		Inactive: "  {{ if .Warning }}{{ \"⚠\" | red }} {{ end }}{{ .Name | cyan }}: age {{ .XIDAge | blue }} ({{ printf \"%.1f%%\" .Wraparound | blue }} towards wraparound)",
+		Details: `
+ --------- Database ----------
+ {{ "Transaction Age:" | faint }}	{{ .XIDAge }}
+ {{ "Freeze Max Age:" | faint }}	{{ .FreezeMaxAge }}{{ if gt .XIDAge .FreezeMaxAge }} {{ "anti-wraparound vacuums are due" | red }}{{ end }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		name := strings.Replace(strings.ToLower(items[index].Name), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	return selecter("Databases By Transaction Age", items, searcher, templates)
+}
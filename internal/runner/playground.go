@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsteenb2/promptui"
+)
+
+// playgroundCellWidth is the widest a column is shown in a result.
+const playgroundCellWidth = 40
+
+// Playground runs statements against the database in a transaction that is
+// rolled back when the playground is left, nothing done in it is kept. The
+// seed statements are run first, e.g. a suggested index to try out before
+// running the queries it is meant for.
+func (r *Runner) Playground(ctx context.Context, seed ...string) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	return r.pgClient.Sandbox(ctx, func(tx *sqlx.Tx) error {
+		if err := postgres.LimitSandbox(ctx, tx); err != nil {
+			return err
+		}
+
+		for _, query := range seed {
+			if !postgres.IsReadOnly(query) && !confirmWrite(query) {
+				continue
+			}
+			if err := playgroundRun(ctx, tx, query); err != nil {
+				return err
+			}
+		}
+		for {
+			query, err := (&promptui.Prompt{
+				Label: "SQL (empty leaves the playground and rolls everything back)",
+			}).Run()
+			overwritePrevLine()
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(query) == "" {
+				return nil
+			}
+			if err := playgroundRun(ctx, tx, query); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// confirmWrite warns that a statement holds its locks until the playground
+// is left, and asks whether to run it. A schema change locks its table, any
+// other write the rows it touches.
+func confirmWrite(query string) bool {
+	locks := "the rows it changes"
+	if postgres.IsDDL(query) {
+		locks = "the table"
+	}
+	_, err := (&promptui.Prompt{
+		Label:     "Run " + truncate(query, 60) + "? It locks " + locks + " until the playground is left (at most " + postgres.SandboxIdleTimeout + " idle)",
+		IsConfirm: true,
+	}).Run()
+	overwritePrevLine()
+	// anything but y declines the confirmation
+	return err == nil
+}
+
+// playgroundRun runs a statement and shows its result. Errors from the
+// statement itself are shown rather than returned, so the session goes on.
+func playgroundRun(ctx context.Context, tx *sqlx.Tx, query string) error {
+	res, err := postgres.SandboxExec(ctx, tx, query)
+	if errors.Is(err, postgres.ErrSandboxAborted) {
+		return err
+	}
+	if err != nil {
+		return viewLines(query, []string{"ERROR: " + err.Error()})
+	}
+	return viewLines(query, resultLines(res))
+}
+
+// resultLines lays a result out as an aligned table.
+func resultLines(res postgres.Result) []string {
+	if len(res.Columns) == 0 {
+		return []string{fmt.Sprintf("ok, %d row(s) affected", res.Affected)}
+	}
+
+	cells := [][]string{res.Columns}
+	for _, row := range res.Rows {
+		line := make([]string, len(row.Values))
+		for i, v := range row.Values {
+			line[i] = strings.Replace(postgres.FormatValue(v), "\n", " ", -1)
+		}
+		cells = append(cells, line)
+	}
+
+	widths := make([]int, len(res.Columns))
+	for _, line := range cells {
+		for i, cell := range line {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+			if widths[i] > playgroundCellWidth {
+				widths[i] = playgroundCellWidth
+			}
+		}
+	}
+
+	var lines []string
+	for n, line := range cells {
+		parts := make([]string, len(line))
+		for i, cell := range line {
+			if utf8.RuneCountInString(cell) > widths[i] {
+				cell = string([]rune(cell)[:widths[i]-1]) + "…"
+			}
+			parts[i] = cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+		}
+		lines = append(lines, strings.Join(parts, " │ "))
+		if n == 0 {
+			sep := make([]string, len(widths))
+			for i, w := range widths {
+				sep[i] = strings.Repeat("─", w)
+			}
+			lines = append(lines, strings.Join(sep, "─┼─"))
+		}
+	}
+	return append(lines, fmt.Sprintf("(%d row(s))", len(res.Rows)))
+}
@@ -0,0 +1,261 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jsteenb2/promptui"
+	"github.com/lib/pq"
+)
+
+type rlsTableItem struct {
+	postgres.RLSTable
+	Status string
+}
+
+// RowLevelSecurity lists the tables using row-level security. For a selected
+// table its policies can be viewed, or a query run as two roles in a
+// rolled back transaction to compare the rows each of them sees.
+func (r *Runner) RowLevelSecurity(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	tables, err := r.pgClient.RLSTables(ctx)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return selecter("Row-Level Security", []string{"no tables use row-level security, back"}, nil, nil)
+	}
+	items := make([]rlsTableItem, 0, len(tables))
+	for _, t := range tables {
+		status := "enabled"
+		switch {
+		case !t.Enabled:
+			status = "disabled, policies are not applied"
+		case t.Forced:
+			status = "enabled and forced for the owner"
+		}
+		items = append(items, rlsTableItem{RLSTable: t, Status: status})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ if not .Enabled }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | bold | green }}.{{ .Table | bold | cyan }}: {{ .Policies | bold | blue }} policies",
+		Inactive: "  {{ if not .Enabled }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | green }}.{{ .Table | cyan }}: {{ .Policies | blue }} policies",
+		Details: `
+ --------- Row-Level Security ----------
+ {{ "Owner:" | faint }}	{{ .Owner }}
+ {{ "Status:" | faint }}	{{ .Status }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		t := items[index]
+		name := strings.Replace(strings.ToLower(t.Schema+"."+t.Table), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	i, err := selectIndex("Row-Level Security", items, searcher, templates)
+	if err != nil {
+		return err
+	}
+	table := items[i].RLSTable
+
+	const (
+		viewPolicies = "view policies"
+		compareRoles = "compare rows seen by two roles"
+	)
+	action, err := selectStr(table.Schema+"."+table.Table, []string{viewPolicies, compareRoles, "back"})
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+	switch action {
+	case viewPolicies:
+		return r.policies(ctx, table)
+	case compareRoles:
+		return r.compareRoles(ctx, table)
+	default:
+		return nil
+	}
+}
+
+type policyItem struct {
+	postgres.Policy
+	RoleList string
+}
+
+func (r *Runner) policies(ctx context.Context, table postgres.RLSTable) error {
+	policies, err := r.pgClient.Policies(ctx, table.Schema, table.Table)
+	if err != nil {
+		return err
+	}
+	label := "Policies On " + table.Schema + "." + table.Table
+	if len(policies) == 0 {
+		return selecter(label, []string{"no policies, every row is hidden from roles other than the owner, back"}, nil, nil)
+	}
+	items := make([]policyItem, 0, len(policies))
+	for _, p := range policies {
+		items = append(items, policyItem{Policy: p, RoleList: strings.Join(p.Roles, ", ")})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Name | bold | cyan }} {{ .Command | bold | blue }} to {{ .RoleList | bold | green }} {{ .Permissive | faint }}",
+		Inactive: "  {{ .Name | cyan }} {{ .Command | blue }} to {{ .RoleList | green }} {{ .Permissive | faint }}",
+		Details: `
+ --------- Policy ----------
+ {{ "Command:" | faint }}	{{ .Command }} ({{ .Permissive }})
+ {{ "Roles:" | faint }}	{{ .RoleList }}
+ {{ "Using:" | faint }}	{{ if .Using }}{{ .Using }}{{ else }}-{{ end }}
+ {{ "With Check:" | faint }}	{{ if .WithCheck }}{{ .WithCheck }}{{ else }}-{{ end }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		name := strings.Replace(strings.ToLower(items[index].Name), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	return selecter(label, items, searcher, templates)
+}
+
+// compareRoles runs a query as two roles and shows the rows each of them
+// sees side by side.
+func (r *Runner) compareRoles(ctx context.Context, table postgres.RLSTable) error {
+	roles, err := r.pgClient.Roles(ctx)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]postgres.Role, len(roles))
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		byName[role.Name] = role
+		names = append(names, role.Name)
+	}
+
+	query, err := (&promptui.Prompt{
+		Label:     "Query",
+		Default:   fmt.Sprintf("SELECT * FROM %s.%s LIMIT 100", pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(table.Table)),
+		AllowEdit: true,
+		Validate:  validateEmptyInput("query"),
+	}).Run()
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+	first, err := selectStr("Run as", names)
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+	second, err := selectStr("Compare with", names)
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	for _, name := range []string{first, second} {
+		if note := rlsBypass(byName[name], table); note != "" {
+			lines = append(lines, name+" "+note)
+		}
+	}
+
+	err = r.pgClient.Sandbox(ctx, func(tx *sqlx.Tx) error {
+		if err := postgres.LimitSandbox(ctx, tx); err != nil {
+			return err
+		}
+		a, errA := postgres.SandboxExecAs(ctx, tx, first, query)
+		if errors.Is(errA, postgres.ErrSandboxAborted) {
+			return errA
+		}
+		b, errB := postgres.SandboxExecAs(ctx, tx, second, query)
+		if errors.Is(errB, postgres.ErrSandboxAborted) {
+			return errB
+		}
+		lines = append(lines, compareLines(first, second, a, b, errA, errB)...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return viewLines(query, lines)
+}
+
+// rlsBypass explains why policies do not apply to a role, if they do not.
+func rlsBypass(role postgres.Role, table postgres.RLSTable) string {
+	switch {
+	case role.Superuser:
+		return "is a superuser and bypasses row-level security"
+	case role.BypassRLS:
+		return "has BYPASSRLS and bypasses row-level security"
+	case role.Name == table.Owner && !table.Forced:
+		return "owns the table, policies do not apply to it unless forced"
+	default:
+		return ""
+	}
+}
+
+// compareLines lays out the results of the same query run as two roles,
+// marking every distinct row with the roles that see it.
+func compareLines(first, second string, a, b postgres.Result, errA, errB error) []string {
+	var lines []string
+	for _, run := range []struct {
+		role string
+		res  postgres.Result
+		err  error
+	}{{first, a, errA}, {second, b, errB}} {
+		switch {
+		case run.err != nil:
+			lines = append(lines, fmt.Sprintf("%s: ERROR: %s", run.role, run.err))
+		case len(run.res.Columns) == 0:
+			lines = append(lines, fmt.Sprintf("%s: ok, %d row(s) affected", run.role, run.res.Affected))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %d row(s)", run.role, len(run.res.Rows)))
+		}
+	}
+
+	var (
+		order        []string
+		seenA, seenB = make(map[string]bool), make(map[string]bool)
+	)
+	for _, row := range a.Rows {
+		s := row.Summary()
+		if !seenA[s] {
+			order = append(order, s)
+		}
+		seenA[s] = true
+	}
+	for _, row := range b.Rows {
+		s := row.Summary()
+		if !seenA[s] && !seenB[s] {
+			order = append(order, s)
+		}
+		seenB[s] = true
+	}
+	if len(order) == 0 {
+		return lines
+	}
+
+	width := len(first)
+	if len(second) > width {
+		width = len(second)
+	}
+	mark := func(seen bool) string {
+		if seen {
+			return fmt.Sprintf("%-*s", width, "✓")
+		}
+		return fmt.Sprintf("%-*s", width, "·")
+	}
+	lines = append(lines, "", fmt.Sprintf("%-*s  %-*s  row", width, first, width, second))
+	for _, s := range order {
+		lines = append(lines, mark(seenA[s])+"  "+mark(seenB[s])+"  "+s)
+	}
+	return lines
+}
@@ -0,0 +1,192 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+const rowLimit = 100
+
+// rowLocation is a stop in the row browser's navigation history.
+type rowLocation struct {
+	Schema string
+	Table  string
+	Filter postgres.Filter
+}
+
+func (l rowLocation) String() string {
+	name := l.Schema + "." + l.Table
+	if len(l.Filter.Columns) == 0 {
+		return name
+	}
+	var conds []string
+	for i, c := range l.Filter.Columns {
+		conds = append(conds, c+"="+postgres.FormatValue(l.Filter.Values[i]))
+	}
+	return name + " where " + strings.Join(conds, " and ")
+}
+
+type rowItem struct {
+	Label   string
+	Details string
+	row     *postgres.Row
+}
+
+type rowAction struct {
+	Label string
+	Row   string
+	next  *rowLocation
+}
+
+// BrowseRows lists the rows of a table and lets the user follow foreign keys
+// from a selected row to the row it references, or to the rows referencing it.
+// Every hop is kept in a history so it can be walked back.
+func (r *Runner) BrowseRows(ctx context.Context, schema, table string) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	history := []rowLocation{{Schema: schema, Table: table}}
+	for len(history) > 0 {
+		loc := history[len(history)-1]
+		next, err := r.browseLocation(ctx, loc, len(history) > 1)
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			history = history[:len(history)-1]
+			continue
+		}
+		history = append(history, *next)
+	}
+	return nil
+}
+
+// browseLocation shows the rows for a single location. A nil location is
+// returned when the user asks to go back.
+func (r *Runner) browseLocation(ctx context.Context, loc rowLocation, hasHistory bool) (*rowLocation, error) {
+	rows, err := r.pgClient.Rows(ctx, loc.Schema, loc.Table, loc.Filter, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	back := "« back"
+	if hasHistory {
+		back = "« back from " + loc.Schema + "." + loc.Table
+	}
+	items := []rowItem{{Label: back}}
+	for i := range rows {
+		items = append(items, rowItem{
+			Label:   rows[i].Summary(),
+			Details: rows[i].Details(),
+			row:     &rows[i],
+		})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Label | bold | cyan }}",
+		Inactive: "  {{ .Label | cyan }}",
+		Details: `
+ --------- Row ----------
+{{ .Details }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		label := strings.Replace(strings.ToLower(items[index].Label), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(label, input)
+	}
+
+	for {
+		i, err := selectIndex(loc.String(), items, searcher, templates)
+		if err != nil || items[i].row == nil {
+			return nil, err
+		}
+
+		next, err := r.rowActions(ctx, loc, *items[i].row)
+		if err != nil || next != nil {
+			return next, err
+		}
+	}
+}
+
+// rowActions offers the outgoing and incoming foreign keys for a row and
+// returns the location the chosen key leads to. A nil location is returned
+// when the user goes back to the row list.
+func (r *Runner) rowActions(ctx context.Context, loc rowLocation, row postgres.Row) (*rowLocation, error) {
+	outgoing, err := r.catalog.ForeignKeys(ctx, loc.Schema, loc.Table)
+	if err != nil {
+		return nil, err
+	}
+	incoming, err := r.catalog.ReferencingKeys(ctx, loc.Schema, loc.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []rowAction{{Label: "« back to " + loc.Schema + "." + loc.Table}}
+	for _, fk := range outgoing {
+		actions = append(actions, rowAction{
+			Label: fmt.Sprintf("→ %s.%s(%s) via %s", fk.RefSchema, fk.RefTable, strings.Join(fk.RefColumns, ", "), fk.Name),
+			next:  followKey(row, fk.Columns, fk.RefSchema, fk.RefTable, fk.RefColumns),
+		})
+	}
+	for _, fk := range incoming {
+		actions = append(actions, rowAction{
+			Label: fmt.Sprintf("← %s.%s(%s) via %s", fk.Schema, fk.Table, strings.Join(fk.Columns, ", "), fk.Name),
+			next:  followKey(row, fk.RefColumns, fk.Schema, fk.Table, fk.Columns),
+		})
+	}
+	for i := range actions {
+		if i > 0 && actions[i].next == nil {
+			actions[i].Label += " (NULL)"
+		}
+		actions[i].Row = row.Details()
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Label | bold | cyan }}",
+		Inactive: "  {{ .Label | cyan }}",
+		Details: `
+ --------- Row ----------
+{{ .Row }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		label := strings.Replace(strings.ToLower(actions[index].Label), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(label, input)
+	}
+
+	for {
+		i, err := selectIndex("Follow Key", actions, searcher, templates)
+		if err != nil || i == 0 {
+			return nil, err
+		}
+		if actions[i].next != nil {
+			return actions[i].next, nil
+		}
+	}
+}
+
+// followKey builds the location holding the rows whose toCols match the row's
+// fromCols. It returns nil when any of the key's values is NULL, since such a
+// row does not take part in the relationship.
+func followKey(row postgres.Row, fromCols []string, schema, table string, toCols []string) *rowLocation {
+	next := rowLocation{Schema: schema, Table: table}
+	for i, col := range fromCols {
+		v, ok := row.Value(col)
+		if !ok || v == nil {
+			return nil
+		}
+		next.Filter.Columns = append(next.Filter.Columns, toCols[i])
+		next.Filter.Values = append(next.Filter.Values, v)
+	}
+	return &next
+}
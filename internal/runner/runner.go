@@ -41,16 +41,33 @@ type (
 	}
 )
 
+// errOffline is returned by the parts of the explorer that need a live
+// database when browsing a snapshot.
+var errOffline = errors.New("not available when exploring a snapshot")
+
 type Runner struct {
 	db       *sqlx.DB
+	cfg      CFG
 	pgClient *postgres.Client
+	catalog  postgres.Catalog
 }
 
-func New(db *sql.DB) *Runner {
+func New(db *sql.DB, cfg CFG) *Runner {
 	dbx := sqlx.NewDb(db, "postgres")
+	pgClient := postgres.New(dbx)
 	return &Runner{
 		db:       dbx,
-		pgClient: postgres.New(dbx),
+		cfg:      cfg,
+		pgClient: pgClient,
+		catalog:  pgClient,
+	}
+}
+
+// NewOffline creates a Runner that explores a snapshot instead of a live
+// database.
+func NewOffline(snap *postgres.Snapshot) *Runner {
+	return &Runner{
+		catalog: postgres.NewSnapshotCatalog(snap),
 	}
 }
 
@@ -58,6 +75,10 @@ func (r *Runner) Run(ctx context.Context, debug bool) error {
 	var err error
 	for fn := startState.Fn; fn != nil; {
 		fn, err = fn(ctx, r)
+		if err == errOffline {
+			fmt.Fprintln(os.Stderr, err)
+			err = nil
+		}
 		if fn == nil && err == nil {
 			fn = startState.Fn
 		}
@@ -66,9 +87,17 @@ func (r *Runner) Run(ctx context.Context, debug bool) error {
 }
 
 func (r *Runner) Close() error {
+	if r.db == nil {
+		return nil
+	}
 	return r.db.Close()
 }
 
+// offline reports whether the runner is exploring a snapshot.
+func (r *Runner) offline() bool {
+	return r.pgClient == nil
+}
+
 var (
 	schemaTemplates = func() *promptui.SelectTemplates {
 		return &promptui.SelectTemplates{
@@ -95,7 +124,7 @@ var (
 )
 
 func (r *Runner) Schemas(ctx context.Context) error {
-	schemas, err := r.pgClient.Schemas(ctx)
+	schemas, err := r.catalog.Schemas(ctx)
 	if err != nil {
 		return err
 	}
@@ -103,7 +132,7 @@ func (r *Runner) Schemas(ctx context.Context) error {
 }
 
 func (r *Runner) SchemasUserCreated(ctx context.Context) error {
-	schemas, err := r.pgClient.SchemasUserCreated(ctx)
+	schemas, err := r.catalog.SchemasUserCreated(ctx)
 	if err != nil {
 		return err
 	}
@@ -116,7 +145,7 @@ func (r *Runner) SchemasUserCreated(ctx context.Context) error {
 }
 
 func (r *Runner) Tables(ctx context.Context) error {
-	tables, err := r.pgClient.Tables(ctx)
+	tables, err := r.catalog.Tables(ctx)
 	if err != nil {
 		return err
 	}
@@ -142,16 +171,51 @@ func (r *Runner) Tables(ctx context.Context) error {
 		return strings.Contains(combined, input)
 	}
 
-	return selecter("Tables", tables, searcher, templates)
+	i, err := selectIndex("Tables", tables, searcher, templates)
+	if err != nil {
+		return err
+	}
+	if r.offline() {
+		return r.tableColumns(ctx, tables[i].Schema, tables[i].Name)
+	}
+	return r.BrowseRows(ctx, tables[i].Schema, tables[i].Name)
+}
+
+// tableColumns lists the columns of a table, it stands in for the row browser
+// when there are no rows to browse.
+func (r *Runner) tableColumns(ctx context.Context, schema, table string) error {
+	cols, err := r.catalog.Columns(ctx, schema)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	for _, c := range cols {
+		if c.Table != table {
+			continue
+		}
+		line := c.Name + " " + c.Type
+		if c.NotNull {
+			line += " not null"
+		}
+		if c.Default != "" {
+			line += " default " + c.Default
+		}
+		if c.PrimaryKey {
+			line += " (primary key)"
+		}
+		lines = append(lines, line)
+	}
+	return viewLines(schema+"."+table, lines)
 }
 
 func (r *Runner) DescribeTable(ctx context.Context, table string) error {
-	_, err := r.pgClient.DescribeTable(ctx, table)
+	_, err := r.catalog.DescribeTable(ctx, table)
 	return err
 }
 
 func (r *Runner) Views(ctx context.Context) error {
-	views, err := r.pgClient.Views(ctx)
+	views, err := r.catalog.Views(ctx)
 	if err != nil {
 		return err
 	}
@@ -176,7 +240,7 @@ func (r *Runner) Views(ctx context.Context) error {
 }
 
 func (r *Runner) MaterializedViews(ctx context.Context) error {
-	views, err := r.pgClient.MaterializedViews(ctx)
+	views, err := r.catalog.MaterializedViews(ctx)
 	if err != nil {
 		return err
 	}
@@ -201,7 +265,7 @@ func (r *Runner) MaterializedViews(ctx context.Context) error {
 }
 
 func (r *Runner) TablesBySchema(ctx context.Context) error {
-	summaries, err := r.pgClient.Schemas(ctx)
+	summaries, err := r.catalog.Schemas(ctx)
 	if err != nil {
 		return err
 	}
@@ -222,7 +286,7 @@ func (r *Runner) TablesBySchema(ctx context.Context) error {
 }
 
 func (r *Runner) TablesBySize(ctx context.Context) error {
-	tables, err := r.pgClient.TablesBySize(ctx)
+	tables, err := r.catalog.TablesBySize(ctx)
 	if err != nil {
 		return err
 	}
@@ -245,6 +309,10 @@ func (r *Runner) TablesBySize(ctx context.Context) error {
 }
 
 func (r *Runner) TablesBySizeWithIndex(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
 	query := `
 		SELECT schemaname as table_schema, relname as table_name,
 				pg_size_pretty(pg_total_relation_size(relid)) as table_data_size,
@@ -280,6 +348,10 @@ func (r *Runner) TablesBySizeWithIndex(ctx context.Context) error {
 }
 
 func (r *Runner) TablesByRows(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
 	query := `
 		SELECT n.nspname as table_schema, c.relname as table_name, c.reltuples as rows
 		FROM pg_class c JOIN pg_namespace n on n.oid = c.relnamespace
@@ -312,6 +384,10 @@ func (r *Runner) TablesByRows(ctx context.Context) error {
 }
 
 func (r *Runner) TablesEmpty(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
 	query := `
 		SELECT n.nspname as table_schema, c.relname as table_name
 		FROM pg_class c JOIN pg_namespace n on n.oid = c.relnamespace
@@ -341,6 +417,10 @@ func (r *Runner) TablesEmpty(ctx context.Context) error {
 }
 
 func (r *Runner) TablesGroupByRows(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
 	query := `
 		SELECT row_count, count(*) as table_count
 		FROM (
@@ -375,6 +455,10 @@ func (r *Runner) TablesGroupByRows(ctx context.Context) error {
 }
 
 func (r *Runner) ColumnsFrequency(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
 	query := `
 		SELECT c.column_name, count(*) as tables,
        		round(100.0*count(*)::decimal /
@@ -424,6 +508,10 @@ func (r *Runner) ColumnsFrequency(ctx context.Context) error {
 }
 
 func (r *Runner) Version(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
 	var version string
 	if err := r.db.GetContext(ctx, &version, `SELECT version()`); err != nil {
 		return err
@@ -433,6 +521,11 @@ func (r *Runner) Version(ctx context.Context) error {
 }
 
 func selecter(name string, items interface{}, searcher list.Searcher, templates *promptui.SelectTemplates) error {
+	_, err := selectIndex(name, items, searcher, templates)
+	return err
+}
+
+func selectIndex(name string, items interface{}, searcher list.Searcher, templates *promptui.SelectTemplates) (int, error) {
 	sel := promptui.Select{
 		HideHelp:          true,
 		Label:             name,
@@ -442,9 +535,24 @@ func selecter(name string, items interface{}, searcher list.Searcher, templates
 		StartInSearchMode: searcher != nil,
 		Templates:         templates,
 	}
-	_, _, err := sel.Run()
+	i, _, err := sel.Run()
 	overwritePrevLine()
-	return err
+	return i, err
+}
+
+// viewLines shows read only lines of text in a scrollable list. Selecting any
+// line returns.
+func viewLines(title string, lines []string) error {
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ . }}",
+		Inactive: "  {{ . }}",
+	}
+
+	searcher := func(input string, index int) bool {
+		return strings.Contains(strings.ToLower(lines[index]), strings.ToLower(input))
+	}
+	return selecter(title, lines, searcher, templates)
 }
 
 func selectSize(templates *promptui.SelectTemplates) int {
@@ -481,7 +589,9 @@ func overwritePrevLine() {
 		clearLine      = "2K"
 		carriageReturn = "\r"
 	)
-	fmt.Print(escPrevLine + clearLine + carriageReturn)
+	// prompts are drawn on stderr, see stderr.go, so that is where they are
+	// cleared from too. This keeps stdout clean when it is redirected.
+	fmt.Fprint(os.Stderr, escPrevLine+clearLine+carriageReturn)
 }
 
 func terminalSize() (width, height int, err error) {
@@ -0,0 +1,254 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+type roleItem struct {
+	postgres.Role
+	Attributes string
+	Expiry     string
+	MemberList string
+	GroupList  string
+}
+
+// Roles lists the roles of the server with their attributes and
+// memberships.
+func (r *Runner) Roles(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	roles, err := r.pgClient.Roles(ctx)
+	if err != nil {
+		return err
+	}
+	items := make([]roleItem, 0, len(roles))
+	for _, role := range roles {
+		expiry := role.ValidUntil
+		if expiry == "" {
+			expiry = "never"
+		}
+		items = append(items, roleItem{
+			Role:       role,
+			Attributes: strings.Join(role.Attributes(), ", "),
+			Expiry:     expiry,
+			MemberList: strings.Join(role.Members, ", "),
+			GroupList:  strings.Join(role.MemberOf, ", "),
+		})
+	}
+	if len(items) == 0 {
+		return selecter("Roles", []string{"back"}, nil, nil)
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ if .Superuser }}{{ .Name | bold | red }}{{ else }}{{ .Name | bold | cyan }}{{ end }} {{ .Attributes | faint }}",
+		Inactive: "  {{ if .Superuser }}{{ .Name | red }}{{ else }}{{ .Name | cyan }}{{ end }} {{ .Attributes | faint }}",
+		Details: `
+ --------- Role ----------
+ {{ "Name:" | faint }}	{{ .Name }}
+ {{ "Attributes:" | faint }}	{{ if .Attributes }}{{ .Attributes }}{{ else }}-{{ end }}
+ {{ "Password Expires:" | faint }}	{{ .Expiry }}
+ {{ "Member Of:" | faint }}	{{ if .GroupList }}{{ .GroupList }}{{ else }}-{{ end }}
+ {{ "Members:" | faint }}	{{ if .MemberList }}{{ .MemberList }}{{ else }}-{{ end }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		name := strings.Replace(strings.ToLower(items[index].Name), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	return selecter("Roles", items, searcher, templates)
+}
+
+// RoleMemberships shows the role membership graph as a tree of the roles
+// granted to other roles.
+func (r *Runner) RoleMemberships(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	memberships, err := r.pgClient.RoleMemberships(ctx)
+	if err != nil {
+		return err
+	}
+	if len(memberships) == 0 {
+		return selecter("Role Memberships", []string{"no role is a member of another, back"}, nil, nil)
+	}
+	return viewLines("Role Memberships", membershipTree(memberships))
+}
+
+func membershipTree(memberships []postgres.RoleMembership) []string {
+	members := make(map[string][]postgres.RoleMembership)
+	isMember := make(map[string]bool)
+	for _, m := range memberships {
+		members[m.Role] = append(members[m.Role], m)
+		isMember[m.Member] = true
+	}
+
+	var roots []string
+	for role := range members {
+		if !isMember[role] {
+			roots = append(roots, role)
+		}
+	}
+	sort.Strings(roots)
+
+	var (
+		lines []string
+		walk  func(role, suffix string, depth int, path map[string]bool)
+	)
+	walk = func(role, suffix string, depth int, path map[string]bool) {
+		prefix := ""
+		if depth > 0 {
+			prefix = strings.Repeat("  ", depth-1) + "└ "
+		}
+		lines = append(lines, prefix+role+suffix)
+		if path[role] {
+			return
+		}
+		path[role] = true
+		defer delete(path, role)
+		for _, m := range members[role] {
+			suffix := ""
+			if m.AdminOption {
+				suffix = " (admin)"
+			}
+			walk(m.Member, suffix, depth+1, path)
+		}
+	}
+	for _, role := range roots {
+		walk(role, "", 0, make(map[string]bool))
+	}
+	return lines
+}
+
+type privilegeObjectItem struct {
+	postgres.PrivilegeObject
+	Label string
+}
+
+// Privileges shows which roles hold which privileges on a selected table,
+// schema or function, including those held through role membership, and the
+// default privileges that apply to new objects of its kind.
+func (r *Runner) Privileges(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	kind, err := selectStr("Privileges On", []string{postgres.PrivilegeTable, postgres.PrivilegeSchema, postgres.PrivilegeFunction})
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+
+	objects, err := r.pgClient.PrivilegeObjects(ctx, kind)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return selecter("Privileges", []string{"no " + kind + "s, back"}, nil, nil)
+	}
+	items := make([]privilegeObjectItem, 0, len(objects))
+	for _, o := range objects {
+		label := o.Schema + "." + o.Name
+		if kind == postgres.PrivilegeSchema {
+			label = o.Name
+		}
+		items = append(items, privilegeObjectItem{PrivilegeObject: o, Label: label})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Label | bold | cyan }} {{ .Owner | faint }}",
+		Inactive: "  {{ .Label | cyan }} {{ .Owner | faint }}",
+	}
+	searcher := func(input string, index int) bool {
+		name := strings.Replace(strings.ToLower(items[index].Label), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	i, err := selectIndex("Privileges On "+strings.Title(kind), items, searcher, templates)
+	if err != nil {
+		return err
+	}
+	obj := items[i]
+
+	privs, err := r.pgClient.ObjectPrivileges(ctx, obj.PrivilegeObject)
+	if err != nil {
+		return err
+	}
+	defaults, err := r.pgClient.DefaultPrivileges(ctx)
+	if err != nil {
+		return err
+	}
+
+	lines := append([]string{"owner " + obj.Owner, ""}, privilegeMatrix(kind, privs)...)
+	lines = append(lines, "", "✓ granted to the role, + through membership or PUBLIC, S superuser, · none")
+	if d := defaultPrivilegeLines(obj.PrivilegeObject, defaults); len(d) > 0 {
+		lines = append(lines, "", "default privileges for new "+kind+"s:")
+		lines = append(lines, d...)
+	}
+	return viewLines(obj.Label, lines)
+}
+
+func privilegeMatrix(kind string, privs []postgres.RolePrivileges) []string {
+	names := postgres.Privileges(kind)
+	width := len("role")
+	for _, p := range privs {
+		if len(p.Role) > width {
+			width = len(p.Role)
+		}
+	}
+
+	header := fmt.Sprintf("%-*s", width, "role")
+	for _, n := range names {
+		header += "  " + n
+	}
+	lines := []string{header}
+
+	for _, p := range privs {
+		direct := make(map[string]bool)
+		for _, d := range p.Direct {
+			direct[d] = true
+		}
+		line := fmt.Sprintf("%-*s", width, p.Role)
+		for i, n := range names {
+			cell := "·"
+			switch {
+			case direct[n]:
+				cell = "✓"
+			case p.Superuser:
+				cell = "S"
+			case i < len(p.Granted) && p.Granted[i]:
+				cell = "+"
+			}
+			line += "  " + cell + strings.Repeat(" ", len(n)-1)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func defaultPrivilegeLines(obj postgres.PrivilegeObject, defaults []postgres.DefaultPrivilege) []string {
+	var lines []string
+	for _, d := range defaults {
+		if d.ObjectType != obj.Kind || (d.Schema != "" && d.Schema != obj.Schema) {
+			continue
+		}
+		scope := "in any schema"
+		if d.Schema != "" {
+			scope = "in " + d.Schema
+		}
+		lines = append(lines, fmt.Sprintf("  created by %s %s: %s to %s", d.Owner, scope, strings.Join(d.Privileges, ", "), d.Grantee))
+	}
+	return lines
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+// sequenceWarnPercent is how much of an int4 sequence's range may be consumed
+// before it is flagged as at risk of running out.
+const sequenceWarnPercent = 50.0
+
+type sequenceItem struct {
+	postgres.Sequence
+	Warning string
+}
+
+func (r *Runner) Sequences(ctx context.Context) error {
+	seqs, err := r.catalog.Sequences(ctx)
+	if err != nil {
+		return err
+	}
+
+	items := make([]sequenceItem, 0, len(seqs))
+	for _, s := range seqs {
+		item := sequenceItem{Sequence: s}
+		if s.IsInt4() && s.PercentUsed >= sequenceWarnPercent {
+			item.Warning = fmt.Sprintf("int4 sequence is %.2f%% consumed", s.PercentUsed)
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		items = append(items, sequenceItem{Sequence: postgres.Sequence{Schema: "back"}})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ if .Warning }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | bold | green }}.{{ .Name | bold | cyan }}: {{ printf \"%.2f%%\" .PercentUsed | bold | blue }}",
+		Inactive: "  {{ if .Warning }}{{ \"⚠\" | red }} {{ end }}{{ .Schema | green }}.{{ .Name | cyan }}: {{ printf \"%.2f%%\" .PercentUsed | blue }}",
+		Details: `
+ --------- Sequence ----------
+ {{ "Owned By:" | faint }}	{{ if .OwnerColumn }}{{ .OwnerTable }}.{{ .OwnerColumn }} ({{ .ColumnType }}){{ else }}-{{ end }}
+ {{ "Data Type:" | faint }}	{{ .DataType }}
+ {{ "Current Value:" | faint }}	{{ if .Used }}{{ .CurrentValue }}{{ else }}not yet used{{ end }}
+ {{ "Increment:" | faint }}	{{ .Increment }}
+ {{ "Max Value:" | faint }}	{{ .MaxValue }}{{ if .Cycle }} (cycles){{ end }}
+ {{ "Consumed:" | faint }}	{{ printf "%.2f%%" .PercentUsed }}{{ if .Warning }} {{ .Warning | red }}{{ end }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		seq := items[index]
+		name := strings.Replace(strings.ToLower(seq.Name), " ", "", -1)
+		schema := strings.Replace(strings.ToLower(seq.Schema), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(schema+"."+name, input)
+	}
+	return selecter("Sequences", items, searcher, templates)
+}
@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+const (
+	allSettings        = "all settings"
+	nonDefaultSettings = "non-default only"
+	pendingRestart     = "pending restart"
+	settingOverrides   = "role and database overrides"
+)
+
+type settingItem struct {
+	postgres.Setting
+	Current    string
+	Default    string
+	Origin     string
+	NonDefault bool
+	Restart    bool
+	Overrides  string
+}
+
+// Settings browses the server configuration, optionally only the settings
+// changed from their defaults or waiting on a restart, or the overrides set
+// per role and database.
+func (r *Runner) Settings(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	filter, err := selectStr("Settings", []string{allSettings, nonDefaultSettings, pendingRestart, settingOverrides})
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+
+	overrides, err := r.pgClient.SettingOverrides(ctx)
+	if err != nil {
+		return err
+	}
+	if filter == settingOverrides {
+		return viewSettingOverrides(overrides)
+	}
+
+	settings, err := r.pgClient.Settings(ctx)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string][]string)
+	for _, o := range overrides {
+		byName[o.Name] = append(byName[o.Name], overrideScope(o)+" = "+o.Value)
+	}
+
+	var items []settingItem
+	for _, s := range settings {
+		if filter == nonDefaultSettings && !s.NonDefault() && len(byName[s.Name]) == 0 {
+			continue
+		}
+		if filter == pendingRestart && !s.PendingRestart {
+			continue
+		}
+		items = append(items, settingItem{
+			Setting:    s,
+			Current:    s.Value(),
+			Default:    s.Default(),
+			Origin:     s.Origin(),
+			NonDefault: s.NonDefault(),
+			Restart:    s.RequiresRestart(),
+			Overrides:  strings.Join(byName[s.Name], ", "),
+		})
+	}
+	if len(items) == 0 {
+		return selecter("Settings", []string{"no matching settings, back"}, nil, nil)
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ if .PendingRestart }}{{ \"⟳\" | red }} {{ end }}{{ if .NonDefault }}{{ .Name | bold | yellow }}{{ else }}{{ .Name | bold | cyan }}{{ end }} = {{ .Current | bold | blue }}{{ if .Overrides }} {{ \"(overridden)\" | faint }}{{ end }}",
+		Inactive: "  {{ if .PendingRestart }}{{ \"⟳\" | red }} {{ end }}{{ if .NonDefault }}{{ .Name | yellow }}{{ else }}{{ .Name | cyan }}{{ end }} = {{ .Current | blue }}{{ if .Overrides }} {{ \"(overridden)\" | faint }}{{ end }}",
+		Details: `
+ --------- Setting ----------
+ {{ "Name:" | faint }}	{{ .Name }}
+ {{ "Value:" | faint }}	{{ .Current }} (default {{ .Default }})
+ {{ "Source:" | faint }}	{{ .Origin }}
+ {{ "Context:" | faint }}	{{ .Context }}{{ if .Restart }} (requires restart){{ end }}{{ if .PendingRestart }} {{ "changed, pending restart" | red }}{{ end }}
+ {{ "Overrides:" | faint }}	{{ if .Overrides }}{{ .Overrides }}{{ else }}-{{ end }}
+ {{ "Category:" | faint }}	{{ .Category }}
+ {{ "Description:" | faint }}	{{ .Description }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		s := items[index]
+		name := strings.Replace(strings.ToLower(s.Name+s.Category), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	return selecter("Settings ("+filter+")", items, searcher, templates)
+}
+
+type settingOverrideItem struct {
+	postgres.SettingOverride
+	Scope string
+}
+
+func viewSettingOverrides(overrides []postgres.SettingOverride) error {
+	if len(overrides) == 0 {
+		return selecter("Role And Database Overrides", []string{"no overrides, back"}, nil, nil)
+	}
+	items := make([]settingOverrideItem, 0, len(overrides))
+	for _, o := range overrides {
+		items = append(items, settingOverrideItem{SettingOverride: o, Scope: overrideScope(o)})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Name | bold | cyan }} = {{ .Value | bold | blue }} {{ .Scope | bold | green }}",
+		Inactive: "  {{ .Name | cyan }} = {{ .Value | blue }} {{ .Scope | green }}",
+	}
+
+	searcher := func(input string, index int) bool {
+		o := items[index]
+		name := strings.Replace(strings.ToLower(o.Name+o.Scope), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+	return selecter("Role And Database Overrides", items, searcher, templates)
+}
+
+func overrideScope(o postgres.SettingOverride) string {
+	switch {
+	case o.Role != "" && o.Database != "":
+		return "for role " + o.Role + " in database " + o.Database
+	case o.Role != "":
+		return "for role " + o.Role
+	case o.Database != "":
+		return "in database " + o.Database
+	default:
+		return "everywhere"
+	}
+}
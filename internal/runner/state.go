@@ -15,7 +15,7 @@ type state struct {
 }
 
 var (
-	startStates = []state{exploreState, playgroundState}
+	startStates = []state{exploreState, activityState, securityState, diffState, playgroundState}
 
 	startState = state{
 		Name: "Back to Start",
@@ -59,6 +59,36 @@ var (
 		},
 	}
 
+	functionState = state{
+		Name: "Functions",
+		Fn: func(ctx context.Context, r *Runner) (StateFn, error) {
+			userCreated := state{
+				Name: "User Created",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.FunctionsUserCreated(ctx) },
+			}
+			all := state{
+				Name: "All",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Functions(ctx) },
+			}
+			return selectState("Function Options", userCreated, all)
+		},
+	}
+
+	typeState = state{
+		Name: "Types",
+		Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Types(ctx) },
+	}
+
+	dependencyState = state{
+		Name: "Dependencies",
+		Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Dependencies(ctx) },
+	}
+
+	erdState = state{
+		Name: "ER Diagram",
+		Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.ERDiagram(ctx) },
+	}
+
 	statsState = state{
 		Name: "Stats",
 		Fn: func(ctx context.Context, r *Runner) (StateFn, error) {
@@ -91,6 +121,32 @@ var (
 					Name: "Column Name Frequencies",
 					Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.ColumnsFrequency(ctx) },
 				},
+				{
+					Name: "Column Statistics",
+					Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.ColumnStatistics(ctx) },
+				},
+				{
+					Name: "Sequences By Consumption",
+					Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Sequences(ctx) },
+				},
+				maintenanceState,
+				ioState,
+				{
+					Name: "Bloat",
+					Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Bloat(ctx) },
+				},
+				{
+					Name: "Top Queries",
+					Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.TopQueries(ctx) },
+				},
+				{
+					Name: "Index Health",
+					Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.IndexHealth(ctx) },
+				},
+				{
+					Name: "Settings",
+					Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Settings(ctx) },
+				},
 				{
 					Name: "Postgres Version",
 					Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Version(ctx) },
@@ -100,18 +156,91 @@ var (
 		},
 	}
 
+	maintenanceState = state{
+		Name: "Maintenance",
+		Fn: func(ctx context.Context, r *Runner) (StateFn, error) {
+			deadTuples := state{
+				Name: "Tables By Dead Tuples",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.TablesByDeadTuples(ctx) },
+			}
+			tableAge := state{
+				Name: "Tables By Transaction Age",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.TablesByTransactionAge(ctx) },
+			}
+			databaseAge := state{
+				Name: "Databases By Transaction Age",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.DatabasesByTransactionAge(ctx) },
+			}
+			return selectState("Maintenance", deadTuples, tableAge, databaseAge)
+		},
+	}
+
+	ioState = state{
+		Name: "Cache & I/O",
+		Fn: func(ctx context.Context, r *Runner) (StateFn, error) {
+			tableHits := state{
+				Name: "Tables By Cache Hit Ratio",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.TablesByCacheHit(ctx) },
+			}
+			seqScans := state{
+				Name: "Tables By Sequential Scans",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.TablesBySeqScans(ctx) },
+			}
+			indexHits := state{
+				Name: "Indexes By Cache Hit Ratio",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.IndexesByCacheHit(ctx) },
+			}
+			return selectState("Cache & I/O", tableHits, seqScans, indexHits)
+		},
+	}
+
+	securityState = state{
+		Name: "Security",
+		Fn: func(ctx context.Context, r *Runner) (StateFn, error) {
+			roles := state{
+				Name: "Roles",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Roles(ctx) },
+			}
+			memberships := state{
+				Name: "Role Memberships",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.RoleMemberships(ctx) },
+			}
+			privileges := state{
+				Name: "Privileges",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Privileges(ctx) },
+			}
+			rls := state{
+				Name: "Row-Level Security",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.RowLevelSecurity(ctx) },
+			}
+			audit := state{
+				Name: "Audit",
+				Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Audit(ctx) },
+			}
+			return selectState("Security", roles, memberships, privileges, rls, audit)
+		},
+	}
+
 	exploreState = state{
 		Name: "Explore",
 		Fn: func(ctx context.Context, r *Runner) (StateFn, error) {
-			return selectState("Where too?", schemaState, tableState, viewState, statsState)
+			return selectState("Where too?", schemaState, tableState, viewState, functionState, typeState, dependencyState, erdState, statsState)
 		},
 	}
 
+	activityState = state{
+		Name: "Activity",
+		Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Activity(ctx) },
+	}
+
+	diffState = state{
+		Name: "Diff",
+		Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Diff(ctx) },
+	}
+
 	playgroundState = state{
 		Name: "PlayGround",
-		Fn: func(ctx context.Context, r *Runner) (StateFn, error) {
-			return nil, nil
-		},
+		Fn:   func(ctx context.Context, r *Runner) (StateFn, error) { return nil, r.Playground(ctx) },
 	}
 )
 
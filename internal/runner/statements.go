@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+// topQueriesLimit is how many queries the top queries list shows.
+const topQueriesLimit = 200
+
+type queryStatItem struct {
+	postgres.QueryStat
+	Summary string
+}
+
+// TopQueries lists the queries tracked by pg_stat_statements in a chosen
+// order. A selected query can be explained or run in the playground after
+// filling in its parameters.
+func (r *Runner) TopQueries(ctx context.Context) error {
+	if r.offline() {
+		return errOffline
+	}
+
+	installed, err := r.pgClient.HasExtension(ctx, "pg_stat_statements")
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return viewLines("Top Queries", []string{
+			"the pg_stat_statements extension is not installed, add it to",
+			"shared_preload_libraries and run CREATE EXTENSION pg_stat_statements",
+		})
+	}
+
+	var orders []string
+	for order := range postgres.QueryStatOrders {
+		orders = append(orders, order)
+	}
+	sort.Strings(orders)
+	order, err := selectStr("Order By", orders)
+	overwritePrevLine()
+	if err != nil {
+		return err
+	}
+
+	stats, err := r.pgClient.TopQueries(ctx, order, topQueriesLimit)
+	if err != nil {
+		return err
+	}
+	if len(stats) == 0 {
+		return selecter("Top Queries", []string{"no queries recorded yet, back"}, nil, nil)
+	}
+	items := make([]queryStatItem, 0, len(stats))
+	for _, s := range stats {
+		items = append(items, queryStatItem{QueryStat: s, Summary: strings.Join(strings.Fields(s.Query), " ")})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ printf \"%.0fms\" .TotalTime | bold | red }} {{ printf \"%.2fms\" .MeanTime | bold | blue }} {{ .Calls | bold | green }} {{ .Summary | bold | cyan }}",
+		Inactive: "  {{ printf \"%.0fms\" .TotalTime | red }} {{ printf \"%.2fms\" .MeanTime | blue }} {{ .Calls | green }} {{ .Summary | cyan }}",
+		Details: `
+ --------- Query ----------
+ {{ "User:" | faint }}	{{ .Username }}@{{ .Database }}
+ {{ "Time:" | faint }}	{{ printf "%.0fms total (%.1f%% of all), %.2fms mean" .TotalTime .PercentTotalTime .MeanTime }}
+ {{ "Calls:" | faint }}	{{ .Calls }} calls, {{ .Rows }} rows
+ {{ "Shared Blocks:" | faint }}	{{ .SharedBlksHit }} hit, {{ .SharedBlksRead }} read ({{ printf "%.1f%%" .HitPercent }} hit)
+ {{ "Temp Blocks:" | faint }}	{{ .TempBlksRead }} read, {{ .TempBlksWritten }} written
+ {{ "Query:" | faint }}	{{ .Summary }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		q := strings.Replace(strings.ToLower(items[index].Summary), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(q, input)
+	}
+
+	i, err := selectIndex("Top Queries By "+strings.Title(order), items, searcher, templates)
+	if err != nil {
+		return err
+	}
+
+	const (
+		explain        = "EXPLAIN in playground"
+		explainAnalyze = "EXPLAIN ANALYZE in playground (runs the query, then rolls back)"
+		run            = "run in playground"
+	)
+	action, err := selectStr(items[i].Summary, []string{explain, explainAnalyze, run, "back"})
+	overwritePrevLine()
+	if err != nil || action == "back" {
+		return err
+	}
+
+	query, err := fillPlaceholders(items[i].Query)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case explain:
+		query = "EXPLAIN " + query
+	case explainAnalyze:
+		query = "EXPLAIN (ANALYZE, BUFFERS) " + query
+	}
+	return r.Playground(ctx, query)
+}
+
+// fillPlaceholders asks for a value for every $n parameter of a normalized
+// query and returns the query with the values filled in.
+func fillPlaceholders(query string) (string, error) {
+	values := make(map[int]string)
+	for _, n := range postgres.Placeholders(query) {
+		v, err := (&promptui.Prompt{
+			Label:    fmt.Sprintf("Value for $%d as a SQL literal, e.g. 42 or 'text'", n),
+			Validate: validateEmptyInput("value"),
+		}).Run()
+		overwritePrevLine()
+		if err != nil {
+			return "", err
+		}
+		values[n] = v
+	}
+	return postgres.FillPlaceholders(query, values), nil
+}
@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+
+	"github.com/jsteenb2/promptui"
+)
+
+func (r *Runner) Types(ctx context.Context) error {
+	types, err := r.catalog.Types(ctx)
+	if err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		return selecter("Types", []string{"back"}, nil, nil)
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "» {{ .Schema | bold | green }}.{{ .Name | bold | cyan }} ({{ .Kind | bold | blue }})",
+		Inactive: "  {{ .Schema | green }}.{{ .Name | cyan }} ({{ .Kind | blue }})",
+		Details: `
+ --------- Type ----------
+ {{ "Name:" | faint }}	{{ .Schema }}.{{ .Name }}
+ {{ "Kind:" | faint }}	{{ .Kind }}
+ {{ "Owner:" | faint }}	{{ .Owner }}
+ {{ "Used By:" | faint }}	{{ len .UsedBy }} column(s)`,
+	}
+
+	searcher := func(input string, index int) bool {
+		t := types[index]
+		name := strings.Replace(strings.ToLower(t.Name), " ", "", -1)
+		schema := strings.Replace(strings.ToLower(t.Schema), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(schema+"."+name, input) || strings.Contains(t.Kind, input)
+	}
+
+	i, err := selectIndex("Types", types, searcher, templates)
+	if err != nil {
+		return err
+	}
+	t := types[i]
+	return viewLines(t.Schema+"."+t.Name+" ("+t.Kind+")", typeLines(t))
+}
+
+func typeLines(t postgres.Type) []string {
+	var lines []string
+	if t.Description != "" {
+		lines = append(lines, "description: "+t.Description)
+	}
+
+	switch t.Kind {
+	case "enum":
+		lines = append(lines, "labels (in sort order):")
+		for _, l := range t.Labels {
+			lines = append(lines, "    "+l)
+		}
+	case "domain":
+		lines = append(lines, "base type: "+t.BaseType)
+		if t.NotNull {
+			lines = append(lines, "not null")
+		}
+		if t.Default != "" {
+			lines = append(lines, "default: "+t.Default)
+		}
+		if len(t.Constraints) > 0 {
+			lines = append(lines, "constraints:")
+			for _, c := range t.Constraints {
+				lines = append(lines, "    "+c)
+			}
+		}
+	case "composite":
+		lines = append(lines, "attributes:")
+		for _, a := range t.Attributes {
+			lines = append(lines, "    "+a)
+		}
+	case "range":
+		lines = append(lines, "subtype: "+t.Subtype)
+	}
+
+	if len(t.UsedBy) == 0 {
+		return append(lines, "not used by any columns")
+	}
+	lines = append(lines, "used by:")
+	for _, col := range t.UsedBy {
+		lines = append(lines, "    "+col)
+	}
+	return lines
+}
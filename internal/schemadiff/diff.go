@@ -0,0 +1,459 @@
+// Package schemadiff compares the catalogs of two databases captured as
+// snapshots.
+package schemadiff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jsteenb2/pgkons/internal/postgres"
+)
+
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Object types, in the order changes are reported.
+const (
+	SchemaObject           = "schema"
+	TypeObject             = "type"
+	TableObject            = "table"
+	ColumnObject           = "column"
+	ConstraintObject       = "constraint"
+	IndexObject            = "index"
+	ViewObject             = "view"
+	MaterializedViewObject = "materialized view"
+	FunctionObject         = "function"
+)
+
+var objectOrder = map[string]int{
+	SchemaObject:           0,
+	TypeObject:             1,
+	TableObject:            2,
+	ColumnObject:           3,
+	ConstraintObject:       4,
+	IndexObject:            5,
+	ViewObject:             6,
+	MaterializedViewObject: 7,
+	FunctionObject:         8,
+}
+
+// Change is a difference in a single object. Schema and Table locate the
+// object, Name is its qualified name. Details describe what changed for
+// changed objects, Before and After hold the object's definition where there
+// is a single one, like for views and indexes.
+type Change struct {
+	Kind    Kind
+	Object  string
+	Schema  string
+	Table   string
+	Name    string
+	Details []string
+	Before  string
+	After   string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s %s", c.Kind, c.Object, c.Name)
+}
+
+// Compare lists the changes that turn the catalog in from into the catalog in
+// to. Objects only in to are Added, objects only in from are Removed.
+func Compare(from, to *postgres.Snapshot) []Change {
+	var d differ
+	d.schemas(from, to)
+	d.types(from, to)
+	d.tables(from, to)
+	d.columns(from, to)
+	d.constraints(from, to)
+	d.indexes(from, to)
+	d.views(ViewObject, from.Views, to.Views)
+	d.views(MaterializedViewObject, from.MaterializedViews, to.MaterializedViews)
+	d.functions(from, to)
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		a, b := d.changes[i], d.changes[j]
+		if objectOrder[a.Object] != objectOrder[b.Object] {
+			return objectOrder[a.Object] < objectOrder[b.Object]
+		}
+		return a.Name < b.Name
+	})
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+// compare records added and removed keys between the two maps, and calls
+// changed for every key present in both.
+func compare(fromKeys, toKeys map[string]bool, record func(kind Kind, key string), changed func(key string)) {
+	for k := range toKeys {
+		if !fromKeys[k] {
+			record(Added, k)
+		}
+	}
+	for k := range fromKeys {
+		if !toKeys[k] {
+			record(Removed, k)
+		} else {
+			changed(k)
+		}
+	}
+}
+
+func diffField(details []string, field string, before, after interface{}) []string {
+	b, a := fmt.Sprint(before), fmt.Sprint(after)
+	if b == a {
+		return details
+	}
+	if b == "" {
+		b = "(none)"
+	}
+	if a == "" {
+		a = "(none)"
+	}
+	return append(details, fmt.Sprintf("%s: %s → %s", field, b, a))
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) schemas(from, to *postgres.Snapshot) {
+	index := func(s *postgres.Snapshot) (map[string]bool, map[string]postgres.Schema) {
+		keys, byName := make(map[string]bool), make(map[string]postgres.Schema)
+		for _, sch := range s.Schemas {
+			if postgres.IsSystemSchema(sch.Name) {
+				continue
+			}
+			keys[sch.Name], byName[sch.Name] = true, sch
+		}
+		return keys, byName
+	}
+	fk, fm := index(from)
+	tk, tm := index(to)
+	compare(fk, tk,
+		func(kind Kind, key string) {
+			d.add(Change{Kind: kind, Object: SchemaObject, Schema: key, Name: key})
+		},
+		func(key string) {
+			if details := diffField(nil, "owner", fm[key].Owner, tm[key].Owner); len(details) > 0 {
+				d.add(Change{Kind: Changed, Object: SchemaObject, Schema: key, Name: key, Details: details})
+			}
+		},
+	)
+}
+
+func (d *differ) types(from, to *postgres.Snapshot) {
+	index := func(s *postgres.Snapshot) (map[string]bool, map[string]postgres.Type) {
+		keys, byName := make(map[string]bool), make(map[string]postgres.Type)
+		for _, t := range s.Types {
+			k := t.Schema + "." + t.Name
+			keys[k], byName[k] = true, t
+		}
+		return keys, byName
+	}
+	fk, fm := index(from)
+	tk, tm := index(to)
+	compare(fk, tk,
+		func(kind Kind, key string) {
+			t := tm[key]
+			if kind == Removed {
+				t = fm[key]
+			}
+			d.add(Change{Kind: kind, Object: TypeObject, Schema: t.Schema, Name: key})
+		},
+		func(key string) {
+			a, b := fm[key], tm[key]
+			details := diffField(nil, "kind", a.Kind, b.Kind)
+			details = diffField(details, "labels", strings.Join(a.Labels, ", "), strings.Join(b.Labels, ", "))
+			details = diffField(details, "base type", a.BaseType, b.BaseType)
+			details = diffField(details, "not null", a.NotNull, b.NotNull)
+			details = diffField(details, "default", a.Default, b.Default)
+			details = diffField(details, "constraints", strings.Join(a.Constraints, "; "), strings.Join(b.Constraints, "; "))
+			details = diffField(details, "attributes", strings.Join(a.Attributes, ", "), strings.Join(b.Attributes, ", "))
+			details = diffField(details, "subtype", a.Subtype, b.Subtype)
+			if len(details) > 0 {
+				d.add(Change{Kind: Changed, Object: TypeObject, Schema: a.Schema, Name: key, Details: details})
+			}
+		},
+	)
+}
+
+func (d *differ) tables(from, to *postgres.Snapshot) {
+	index := func(s *postgres.Snapshot) (map[string]bool, map[string]postgres.TableInfo) {
+		keys, byName := make(map[string]bool), make(map[string]postgres.TableInfo)
+		for _, t := range s.Tables {
+			k := t.Schema + "." + t.Name
+			keys[k], byName[k] = true, t
+		}
+		return keys, byName
+	}
+	fk, fm := index(from)
+	tk, tm := index(to)
+	compare(fk, tk,
+		func(kind Kind, key string) {
+			t := tm[key]
+			if kind == Removed {
+				t = fm[key]
+			}
+			d.add(Change{Kind: kind, Object: TableObject, Schema: t.Schema, Table: t.Name, Name: key})
+		},
+		func(key string) {
+			a, b := fm[key], tm[key]
+			details := diffField(nil, "kind", a.Kind, b.Kind)
+			details = diffField(details, "owner", a.Owner, b.Owner)
+			details = diffField(details, "comment", a.Comment, b.Comment)
+			if len(details) > 0 {
+				d.add(Change{Kind: Changed, Object: TableObject, Schema: a.Schema, Table: a.Name, Name: key, Details: details})
+			}
+		},
+	)
+}
+
+// columns only reports on tables present in both catalogs, the columns of
+// added and removed tables come and go with the table.
+func (d *differ) columns(from, to *postgres.Snapshot) {
+	both := make(map[string]bool)
+	for _, t := range from.Tables {
+		both[t.Schema+"."+t.Name] = true
+	}
+	inTo := make(map[string]bool)
+	for _, t := range to.Tables {
+		inTo[t.Schema+"."+t.Name] = true
+	}
+	for k := range both {
+		both[k] = inTo[k]
+	}
+
+	index := func(s *postgres.Snapshot) (map[string]bool, map[string]postgres.TableColumn) {
+		keys, byName := make(map[string]bool), make(map[string]postgres.TableColumn)
+		for _, c := range s.Columns {
+			if !both[c.Schema+"."+c.Table] {
+				continue
+			}
+			k := c.Schema + "." + c.Table + "." + c.Name
+			keys[k], byName[k] = true, c
+		}
+		return keys, byName
+	}
+	fk, fm := index(from)
+	tk, tm := index(to)
+	compare(fk, tk,
+		func(kind Kind, key string) {
+			c := tm[key]
+			if kind == Removed {
+				c = fm[key]
+			}
+			d.add(Change{Kind: kind, Object: ColumnObject, Schema: c.Schema, Table: c.Table, Name: key})
+		},
+		func(key string) {
+			a, b := fm[key], tm[key]
+			details := diffField(nil, "type", a.Type, b.Type)
+			details = diffField(details, "not null", a.NotNull, b.NotNull)
+			details = diffField(details, "default", a.Default, b.Default)
+			details = diffField(details, "generated", a.Generated, b.Generated)
+			details = diffField(details, "identity", a.Identity, b.Identity)
+			details = diffField(details, "comment", a.Comment, b.Comment)
+			if len(details) > 0 {
+				d.add(Change{Kind: Changed, Object: ColumnObject, Schema: a.Schema, Table: a.Table, Name: key, Details: details})
+			}
+		},
+	)
+}
+
+func (d *differ) constraints(from, to *postgres.Snapshot) {
+	index := func(s *postgres.Snapshot) (map[string]bool, map[string]postgres.Constraint) {
+		keys, byName := make(map[string]bool), make(map[string]postgres.Constraint)
+		for _, c := range s.Constraints {
+			k := c.Schema + "." + c.Table + "." + c.Name
+			keys[k], byName[k] = true, c
+		}
+		return keys, byName
+	}
+	fk, fm := index(from)
+	tk, tm := index(to)
+	compare(fk, tk,
+		func(kind Kind, key string) {
+			c := Change{Kind: kind, Object: ConstraintObject, Name: key}
+			if kind == Removed {
+				c.Schema, c.Table, c.Before = fm[key].Schema, fm[key].Table, fm[key].Definition
+			} else {
+				c.Schema, c.Table, c.After = tm[key].Schema, tm[key].Table, tm[key].Definition
+			}
+			d.add(c)
+		},
+		func(key string) {
+			a, b := fm[key], tm[key]
+			if a.Definition != b.Definition {
+				d.add(Change{
+					Kind:    Changed,
+					Object:  ConstraintObject,
+					Schema:  a.Schema,
+					Table:   a.Table,
+					Name:    key,
+					Details: diffField(nil, "definition", a.Definition, b.Definition),
+					Before:  a.Definition,
+					After:   b.Definition,
+				})
+			}
+		},
+	)
+}
+
+func (d *differ) indexes(from, to *postgres.Snapshot) {
+	index := func(s *postgres.Snapshot) (map[string]bool, map[string]postgres.Index) {
+		keys, byName := make(map[string]bool), make(map[string]postgres.Index)
+		for _, idx := range s.Indexes {
+			k := idx.Schema + "." + idx.Name
+			keys[k], byName[k] = true, idx
+		}
+		return keys, byName
+	}
+	fk, fm := index(from)
+	tk, tm := index(to)
+	compare(fk, tk,
+		func(kind Kind, key string) {
+			c := Change{Kind: kind, Object: IndexObject, Name: key}
+			if kind == Removed {
+				c.Schema, c.Table, c.Before = fm[key].Schema, fm[key].Table, fm[key].Definition
+			} else {
+				c.Schema, c.Table, c.After = tm[key].Schema, tm[key].Table, tm[key].Definition
+			}
+			d.add(c)
+		},
+		func(key string) {
+			a, b := fm[key], tm[key]
+			if a.Definition != b.Definition {
+				d.add(Change{
+					Kind:    Changed,
+					Object:  IndexObject,
+					Schema:  a.Schema,
+					Table:   a.Table,
+					Name:    key,
+					Details: diffField(nil, "definition", a.Definition, b.Definition),
+					Before:  a.Definition,
+					After:   b.Definition,
+				})
+			}
+		},
+	)
+}
+
+// views compares views by definition. The live view listing holds a row per
+// referenced table, so views are folded down to one entry per name first.
+func (d *differ) views(object string, from, to []postgres.View) {
+	index := func(views []postgres.View) (map[string]bool, map[string]postgres.View) {
+		keys, byName := make(map[string]bool), make(map[string]postgres.View)
+		for _, v := range views {
+			k := v.ViewSchema + "." + v.Name
+			keys[k], byName[k] = true, v
+		}
+		return keys, byName
+	}
+	fk, fm := index(from)
+	tk, tm := index(to)
+	compare(fk, tk,
+		func(kind Kind, key string) {
+			c := Change{Kind: kind, Object: object, Name: key}
+			if kind == Removed {
+				c.Schema, c.Before = fm[key].ViewSchema, fm[key].Definition
+			} else {
+				c.Schema, c.After = tm[key].ViewSchema, tm[key].Definition
+			}
+			d.add(c)
+		},
+		func(key string) {
+			a, b := fm[key], tm[key]
+			if normalizeSQL(a.Definition) != normalizeSQL(b.Definition) {
+				d.add(Change{
+					Kind:    Changed,
+					Object:  object,
+					Schema:  a.ViewSchema,
+					Name:    key,
+					Details: []string{"definition changed"},
+					Before:  a.Definition,
+					After:   b.Definition,
+				})
+			}
+		},
+	)
+}
+
+func (d *differ) functions(from, to *postgres.Snapshot) {
+	index := func(s *postgres.Snapshot) (map[string]bool, map[string]postgres.Function) {
+		keys, byName := make(map[string]bool), make(map[string]postgres.Function)
+		for _, f := range s.Functions {
+			k := f.Signature()
+			keys[k], byName[k] = true, f
+		}
+		return keys, byName
+	}
+	fk, fm := index(from)
+	tk, tm := index(to)
+	compare(fk, tk,
+		func(kind Kind, key string) {
+			c := Change{Kind: kind, Object: FunctionObject, Name: key}
+			if kind == Removed {
+				c.Schema, c.Before = fm[key].Schema, fm[key].Source
+			} else {
+				c.Schema, c.After = tm[key].Schema, tm[key].Source
+			}
+			d.add(c)
+		},
+		func(key string) {
+			a, b := fm[key], tm[key]
+			details := diffField(nil, "returns", a.Result, b.Result)
+			details = diffField(details, "language", a.Language, b.Language)
+			details = diffField(details, "volatility", a.Volatility, b.Volatility)
+			details = diffField(details, "security definer", a.SecurityDefiner, b.SecurityDefiner)
+			details = diffField(details, "owner", a.Owner, b.Owner)
+			if normalizeSQL(a.Source) != normalizeSQL(b.Source) {
+				details = append(details, "source changed")
+			}
+			if len(details) > 0 {
+				d.add(Change{
+					Kind:    Changed,
+					Object:  FunctionObject,
+					Schema:  a.Schema,
+					Name:    key,
+					Details: details,
+					Before:  a.Source,
+					After:   b.Source,
+				})
+			}
+		},
+	)
+}
+
+// normalizeSQL collapses whitespace so formatting differences between server
+// versions do not show up as changes.
+func normalizeSQL(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteText writes the changes as a human readable report.
+func WriteText(w io.Writer, changes []Change) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "no differences")
+		return err
+	}
+	for _, c := range changes {
+		if _, err := fmt.Fprintln(w, c); err != nil {
+			return err
+		}
+		for _, d := range c.Details {
+			if _, err := fmt.Fprintln(w, "    "+d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}